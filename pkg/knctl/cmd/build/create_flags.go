@@ -63,7 +63,7 @@ func (s *CreateArgsFlags) setWithPrefix(prefix string, cmd *cobra.Command, flags
 	cmd.Flags().StringVar(&s.GitURL, "git-url", "", "Set Git URL")
 	cmd.Flags().StringVar(&s.GitRevision, "git-revision", "", "Set Git revision (examples: https://git-scm.com/docs/gitrevisions#_specifying_revisions)")
 
-	cmd.Flags().StringVar(&s.ServiceAccountName, "service-account", "", "Set service account name for building") // TODO separate
+	cmd.Flags().StringVar(&s.ServiceAccountName, prefix+"service-account", "", "Set service account name for building")
 
 	cmd.Flags().StringVar(&s.TemplateKind, "template-kind", "", "Set to 'cluster' to use ClusterBuildTemplate kind of templates")
 	cmd.Flags().StringVar(&s.TemplateName, "template", "", "Set template name")
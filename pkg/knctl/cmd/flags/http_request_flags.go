@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	ctlcurl "github.com/cppforlife/knctl/pkg/knctl/curl"
+	"github.com/spf13/cobra"
+)
+
+type HTTPRequestFlags struct {
+	Method   string
+	Headers  []string
+	Data     string
+	DataFile string
+	Timeout  time.Duration
+
+	Include      bool
+	ExpectStatus int
+}
+
+func (s *HTTPRequestFlags) Set(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory) {
+	cmd.Flags().StringVarP(&s.Method, "request", "X", "", "Set request method (defaults to GET, or POST if data is provided)")
+	cmd.Flags().StringArrayVarP(&s.Headers, "header", "H", nil, "Set request header (format: 'name: value') (can be specified multiple times)")
+	cmd.Flags().StringVarP(&s.Data, "data", "d", "", "Set request body")
+	cmd.Flags().StringVar(&s.DataFile, "data-file", "", "Set request body from file")
+	cmd.Flags().DurationVar(&s.Timeout, "timeout", 30*time.Second, "Set request timeout")
+
+	cmd.Flags().BoolVarP(&s.Include, "include", "i", false, "Print response status and headers")
+	cmd.Flags().IntVar(&s.ExpectStatus, "expect-status", 0, "Exit with an error if response status does not match (e.g. 200)")
+}
+
+func (s *HTTPRequestFlags) RequestOpts() (ctlcurl.RequestOpts, error) {
+	opts := ctlcurl.RequestOpts{
+		Method:         s.Method,
+		Headers:        s.Headers,
+		Timeout:        s.Timeout,
+		IncludeHeaders: s.Include,
+		ExpectStatus:   s.ExpectStatus,
+	}
+
+	switch {
+	case len(s.Data) > 0 && len(s.DataFile) > 0:
+		return ctlcurl.RequestOpts{}, fmt.Errorf("Expected only one of '--data' or '--data-file' to be specified")
+
+	case len(s.Data) > 0:
+		opts.Data = []byte(s.Data)
+
+	case len(s.DataFile) > 0:
+		data, err := ioutil.ReadFile(s.DataFile)
+		if err != nil {
+			return ctlcurl.RequestOpts{}, fmt.Errorf("Reading data file: %s", err)
+		}
+		opts.Data = data
+	}
+
+	return opts, nil
+}
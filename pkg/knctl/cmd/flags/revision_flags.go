@@ -27,8 +27,12 @@ type RevisionFlags struct {
 }
 
 func (s *RevisionFlags) Set(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory) {
+	s.SetOptional(cmd, flagsFactory)
+	cmd.MarkFlagRequired("revision")
+}
+
+func (s *RevisionFlags) SetOptional(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory) {
 	s.NamespaceFlags.Set(cmd, flagsFactory)
 
 	cmd.Flags().StringVarP(&s.Name, "revision", "r", "", "Specified revision")
-	cmd.MarkFlagRequired("revision")
 }
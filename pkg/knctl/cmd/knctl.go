@@ -109,8 +109,12 @@ Knative docs: https://github.com/knative/docs.`,
 	serviceCmd.AddCommand(cmdsvc.NewShowCmd(cmdsvc.NewShowOptions(o.ui, o.depsFactory), flagsFactory))
 	serviceCmd.AddCommand(cmdsvc.NewDeleteCmd(cmdsvc.NewDeleteOptions(o.ui, o.depsFactory), flagsFactory))
 	serviceCmd.AddCommand(cmdsvc.NewAnnotateCmd(cmdsvc.NewAnnotateOptions(o.ui, o.depsFactory), flagsFactory))
-	serviceCmd.AddCommand(cmdsvc.NewOpenCmd(cmdsvc.NewOpenOptions(o.ui, o.depsFactory), flagsFactory))
+	serviceCmd.AddCommand(cmdsvc.NewOpenCmd(cmdsvc.NewOpenOptions(o.ui, o.depsFactory, cmdcore.CancelSignals{}), flagsFactory))
 	serviceCmd.AddCommand(cmdsvc.NewURLCmd(cmdsvc.NewURLOptions(o.ui, o.depsFactory), flagsFactory))
+	serviceCmd.AddCommand(cmdsvc.NewLoadCmd(cmdsvc.NewLoadOptions(o.ui, o.depsFactory), flagsFactory))
+	serviceCmd.AddCommand(cmdsvc.NewProxyCmd(cmdsvc.NewProxyOptions(o.ui, o.depsFactory, cmdcore.CancelSignals{}), flagsFactory))
+	serviceCmd.AddCommand(cmdsvc.NewPromoteCmd(cmdsvc.NewPromoteOptions(o.ui, o.depsFactory), flagsFactory))
+	serviceCmd.AddCommand(cmdsvc.NewRolloutPercentCmd(cmdsvc.NewRolloutPercentOptions(o.ui, o.depsFactory), flagsFactory))
 	cmd.AddCommand(serviceCmd)
 
 	cmd.AddCommand(cmdsvc.NewDeployCmd(cmdsvc.NewDeployOptions(o.ui, o.configFactory, o.depsFactory), flagsFactory))
@@ -122,8 +126,13 @@ Knative docs: https://github.com/knative/docs.`,
 	revisionCmd.AddCommand(cmdrev.NewShowCmd(cmdrev.NewShowOptions(o.ui, o.depsFactory), flagsFactory))
 	revisionCmd.AddCommand(cmdrev.NewDeleteCmd(cmdrev.NewDeleteOptions(o.ui, o.depsFactory), flagsFactory))
 	revisionCmd.AddCommand(cmdrev.NewTagCmd(cmdrev.NewTagOptions(o.ui, o.depsFactory), flagsFactory))
+	revisionCmd.AddCommand(cmdrev.NewTagHistoryCmd(cmdrev.NewTagHistoryOptions(o.ui, o.depsFactory), flagsFactory))
+	revisionCmd.AddCommand(cmdrev.NewProtectTagCmd(cmdrev.NewProtectTagOptions(o.ui, o.depsFactory), flagsFactory))
+	revisionCmd.AddCommand(cmdrev.NewUnprotectTagCmd(cmdrev.NewUnprotectTagOptions(o.ui, o.depsFactory), flagsFactory))
 	revisionCmd.AddCommand(cmdrev.NewUntagCmd(cmdrev.NewUntagOptions(o.ui, o.depsFactory), flagsFactory))
 	revisionCmd.AddCommand(cmdrev.NewAnnotateCmd(cmdrev.NewAnnotateOptions(o.ui, o.depsFactory), flagsFactory))
+	revisionCmd.AddCommand(cmdrev.NewDiffCmd(cmdrev.NewDiffOptions(o.ui, o.depsFactory), flagsFactory))
+	revisionCmd.AddCommand(cmdrev.NewPruneCmd(cmdrev.NewPruneOptions(o.ui, o.depsFactory), flagsFactory))
 	cmd.AddCommand(revisionCmd)
 
 	routeCmd := cmdrte.NewCmd()
@@ -132,9 +141,12 @@ Knative docs: https://github.com/knative/docs.`,
 	routeCmd.AddCommand(cmdrte.NewDeleteCmd(cmdrte.NewDeleteOptions(o.ui, o.depsFactory), flagsFactory))
 	routeCmd.AddCommand(cmdrte.NewCurlCmd(cmdrte.NewCurlOptions(o.ui, o.depsFactory), flagsFactory))
 	routeCmd.AddCommand(cmdrte.NewAnnotateCmd(cmdrte.NewAnnotateOptions(o.ui, o.depsFactory), flagsFactory))
+	routeCmd.AddCommand(cmdrte.NewSwapCmd(cmdrte.NewSwapOptions(o.ui, o.depsFactory), flagsFactory))
 	cmd.AddCommand(routeCmd)
 
-	cmd.AddCommand(cmdrte.NewCreateCmd(cmdrte.NewCreateOptions(o.ui, o.depsFactory), flagsFactory))
+	rolloutCmd := cmdrte.NewCreateCmd(cmdrte.NewCreateOptions(o.ui, o.depsFactory), flagsFactory)
+	rolloutCmd.AddCommand(cmdrte.NewProgressiveRolloutCmd(cmdrte.NewProgressiveRolloutOptions(o.ui, o.depsFactory), flagsFactory))
+	cmd.AddCommand(rolloutCmd)
 
 	buildCmd := cmdbld.NewCmd()
 	buildCmd.AddCommand(cmdbld.NewCreateCmd(cmdbld.NewCreateOptions(o.ui, o.configFactory, o.depsFactory), flagsFactory))
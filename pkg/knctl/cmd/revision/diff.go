@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	ctldiff "github.com/cppforlife/knctl/pkg/knctl/diff"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/spf13/cobra"
+)
+
+type DiffOptions struct {
+	ui          ui.UI
+	depsFactory cmdcore.DepsFactory
+
+	NamespaceFlags cmdcore.NamespaceFlags
+	RevisionNames  []string
+}
+
+func NewDiffOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *DiffOptions {
+	return &DiffOptions{ui: ui, depsFactory: depsFactory}
+}
+
+func NewDiffCmd(o *DiffOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show differences between two revisions",
+		Long: `Show differences between two revisions.
+
+Compares image, environment variables (including secret and config map references), annotations, scale settings, service account, concurrency and build spec.`,
+		Example: `
+  # Show what changed between previous and latest revisions of service 'svc1' in namespace 'ns1'
+  knctl revision diff -r svc1:previous -r svc1:latest -n ns1`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	o.NamespaceFlags.Set(cmd, flagsFactory)
+	cmd.Flags().StringSliceVarP(&o.RevisionNames, "revision", "r", nil, "Specified revision (specify twice: old revision and new revision)")
+	cmd.MarkFlagRequired("revision")
+	return cmd
+}
+
+func (o *DiffOptions) Run() error {
+	if len(o.RevisionNames) != 2 {
+		return fmt.Errorf("Expected exactly two revisions to be specified but was %d", len(o.RevisionNames))
+	}
+
+	servingClient, err := o.depsFactory.ServingClient()
+	if err != nil {
+		return err
+	}
+
+	buildClient, err := o.depsFactory.BuildClient()
+	if err != nil {
+		return err
+	}
+
+	tags := ctlservice.NewTags(servingClient)
+
+	oldRevisionFlags := cmdflags.RevisionFlags{NamespaceFlags: o.NamespaceFlags, Name: o.RevisionNames[0]}
+
+	oldRevision, err := NewReference(oldRevisionFlags, tags, servingClient).Revision()
+	if err != nil {
+		return err
+	}
+
+	newRevisionFlags := cmdflags.RevisionFlags{NamespaceFlags: o.NamespaceFlags, Name: o.RevisionNames[1]}
+
+	newRevision, err := NewReference(newRevisionFlags, tags, servingClient).Revision()
+	if err != nil {
+		return err
+	}
+
+	diff, err := NewRevisionDiff(oldRevision, newRevision, buildClient).Diff()
+	if err != nil {
+		return err
+	}
+
+	table := uitable.Table{
+		Title:   fmt.Sprintf("Changes from revision '%s' to revision '%s'", oldRevision.Name, newRevision.Name),
+		Content: "changes",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Field"),
+			uitable.NewHeader("Change"),
+			uitable.NewHeader("Old value"),
+			uitable.NewHeader("New value"),
+		},
+	}
+
+	for _, change := range diff.Changes() {
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueString(change.Path),
+			uitable.ValueFmt{
+				V:     uitable.NewValueString(string(change.Type)),
+				Error: change.Type == ctldiff.FieldChangeRemoved,
+			},
+			uitable.NewValueString(change.OldValue),
+			uitable.NewValueString(change.NewValue),
+		})
+	}
+
+	o.ui.PrintTable(table)
+
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/spf13/cobra"
+)
+
+type ProtectTagOptions struct {
+	ui          ui.UI
+	depsFactory cmdcore.DepsFactory
+
+	ServiceFlags cmdflags.ServiceFlags
+	TagFlags     cmdflags.TagFlags
+}
+
+func NewProtectTagOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *ProtectTagOptions {
+	return &ProtectTagOptions{ui: ui, depsFactory: depsFactory}
+}
+
+func NewProtectTagCmd(o *ProtectTagOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "protect-tag",
+		Short: "Protect tags from being moved",
+		Long: fmt.Sprintf(`Protect tags of a service from being moved or removed.
+
+Protected tags are listed in '%s' annotation on the service. Commands that move or remove protected tags fail unless forced (e.g. 'knctl revision tag --force', 'knctl deploy --force-tags').`, ctlservice.TagProtectionAnnotationKey),
+		Example: `
+  # Protect tag 'prod' of service 'svc1' in namespace 'ns1'
+  knctl revision protect-tag -s svc1 -t prod -n ns1`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	o.ServiceFlags.Set(cmd, flagsFactory)
+	o.TagFlags.Set(cmd, flagsFactory)
+	return cmd
+}
+
+func (o *ProtectTagOptions) Run() error {
+	if len(o.TagFlags.Tags) == 0 {
+		return fmt.Errorf("Expected at least one tag to be specified via '--tag' flag")
+	}
+
+	servingClient, err := o.depsFactory.ServingClient()
+	if err != nil {
+		return err
+	}
+
+	protection := ctlservice.NewTagProtection(servingClient, o.ServiceFlags.NamespaceFlags.Name, o.ServiceFlags.Name)
+
+	err = protection.Protect(o.TagFlags.Tags)
+	if err != nil {
+		return err
+	}
+
+	protectedTags, err := protection.List()
+	if err != nil {
+		return err
+	}
+
+	o.ui.PrintLinef("Protected tags of service '%s': %s", o.ServiceFlags.Name, strings.Join(protectedTags, ", "))
+
+	return nil
+}
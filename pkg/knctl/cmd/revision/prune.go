@@ -0,0 +1,177 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type PruneOptions struct {
+	ui          ui.UI
+	depsFactory cmdcore.DepsFactory
+
+	ServiceFlags cmdflags.ServiceFlags
+	PruneFlags   PruneFlags
+}
+
+func NewPruneOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *PruneOptions {
+	return &PruneOptions{ui: ui, depsFactory: depsFactory}
+}
+
+func NewPruneCmd(o *PruneOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old revisions",
+		Long: `Delete old revisions of a service.
+
+Revisions that are tagged, receive (or are configured to receive) traffic from any route, are part of service's release, or are latest created or latest ready revisions of the service are never deleted.
+
+Revisions to delete are shown and confirmation is asked before deleting them (use '--non-interactive' to skip it).`,
+		Example: `
+  # Delete revisions of service 'svc1' in namespace 'ns1' except 5 most recent ones
+  knctl revision prune -s svc1 -n ns1
+
+  # Show which revisions older than 7 days would be deleted, keeping 10 most recent ones
+  knctl revision prune -s svc1 --keep 10 --older-than 7d --dry-run -n ns1`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	o.ServiceFlags.Set(cmd, flagsFactory)
+	o.PruneFlags.Set(cmd, flagsFactory)
+	return cmd
+}
+
+func (o *PruneOptions) Run() error {
+	if o.PruneFlags.Keep < 0 {
+		return fmt.Errorf("Expected number of revisions to keep to be non-negative")
+	}
+
+	olderThan, err := o.PruneFlags.OlderThanDuration()
+	if err != nil {
+		return err
+	}
+
+	servingClient, err := o.depsFactory.ServingClient()
+	if err != nil {
+		return err
+	}
+
+	service, err := servingClient.ServingV1alpha1().Services(o.ServiceFlags.NamespaceFlags.Name).Get(o.ServiceFlags.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	listOpts := metav1.ListOptions{
+		LabelSelector: labels.Set(map[string]string{
+			serving.ConfigurationLabelKey: o.ServiceFlags.Name,
+		}).String(),
+	}
+
+	revisions, err := servingClient.ServingV1alpha1().Revisions(o.ServiceFlags.NamespaceFlags.Name).List(listOpts)
+	if err != nil {
+		return fmt.Errorf("Listing revisions: %s", err)
+	}
+
+	routes, err := servingClient.ServingV1alpha1().Routes(o.ServiceFlags.NamespaceFlags.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("Listing routes: %s", err)
+	}
+
+	tags := ctlservice.NewTags(servingClient)
+	prune := RevisionPrune{Keep: o.PruneFlags.Keep, OlderThan: olderThan, Now: time.Now()}
+
+	decisions := prune.Decide(*service, revisions.Items, routes.Items, tags)
+
+	table := uitable.Table{
+		Title:   fmt.Sprintf("Revisions for service '%s'", o.ServiceFlags.Name),
+		Content: "revisions",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Name"),
+			uitable.NewHeader("Tags"),
+			uitable.NewHeader("Age"),
+			uitable.NewHeader("Action"),
+			uitable.NewHeader("Reason"),
+		},
+	}
+
+	var numDeleted int
+
+	for _, decision := range decisions {
+		action := "keep"
+		if decision.Delete {
+			action = "delete"
+			numDeleted++
+		}
+
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueString(decision.Revision.Name),
+			uitable.NewValueStrings(tags.List(decision.Revision)),
+			cmdcore.NewValueAge(decision.Revision.CreationTimestamp.Time),
+			uitable.ValueFmt{
+				V:     uitable.NewValueString(action),
+				Error: decision.Delete,
+			},
+			uitable.NewValueString(decision.Reason),
+		})
+	}
+
+	o.ui.PrintTable(table)
+
+	if o.PruneFlags.DryRun {
+		o.ui.PrintLinef("%d revision(s) would be deleted (dry run)", numDeleted)
+		return nil
+	}
+
+	if numDeleted == 0 {
+		o.ui.PrintLinef("No revisions to delete")
+		return nil
+	}
+
+	err = o.ui.AskForConfirmation()
+	if err != nil {
+		return err
+	}
+
+	for _, decision := range decisions {
+		if !decision.Delete {
+			continue
+		}
+
+		o.ui.PrintLinef("Deleting revision '%s'", decision.Revision.Name)
+
+		err := servingClient.ServingV1alpha1().Revisions(decision.Revision.Namespace).Delete(decision.Revision.Name, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("Deleting revision: %s", err)
+		}
+	}
+
+	o.ui.PrintLinef("Deleted %d revision(s)", numDeleted)
+
+	return nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	"github.com/spf13/cobra"
+)
+
+type PruneFlags struct {
+	Keep      int
+	OlderThan string
+	DryRun    bool
+}
+
+func (s *PruneFlags) Set(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory) {
+	cmd.Flags().IntVar(&s.Keep, "keep", 5, "Set number of most recent revisions to keep")
+	cmd.Flags().StringVar(&s.OlderThan, "older-than", "", "Only delete revisions older than specified age (e.g. 36h, 7d)")
+	cmd.Flags().BoolVar(&s.DryRun, "dry-run", false, "Show revisions that would be deleted without deleting them")
+}
+
+// OlderThanDuration supports days in addition to Go duration units
+func (s *PruneFlags) OlderThanDuration() (time.Duration, error) {
+	if len(s.OlderThan) == 0 {
+		return 0, nil
+	}
+
+	if strings.HasSuffix(s.OlderThan, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s.OlderThan, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("Expected age '%s' to be a non-negative number of days (e.g. 7d)", s.OlderThan)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	dur, err := time.ParseDuration(s.OlderThan)
+	if err != nil || dur < 0 {
+		return 0, fmt.Errorf("Expected age '%s' to be a non-negative duration (e.g. 36h, 7d)", s.OlderThan)
+	}
+
+	return dur, nil
+}
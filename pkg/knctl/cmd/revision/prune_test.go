@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/revision"
+)
+
+func TestNewPruneCmd_Ok(t *testing.T) {
+	realCmd := NewPruneOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewPruneCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.ExpectBasicConfig()
+	cmd.Execute([]string{
+		"-n", "test-namespace",
+		"-s", "test-service",
+		"--keep", "10",
+		"--older-than", "7d",
+		"--dry-run",
+	})
+	cmd.ExpectReachesExecution()
+
+	DeepEqual(t, realCmd.ServiceFlags,
+		cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-service"})
+	DeepEqual(t, realCmd.PruneFlags, PruneFlags{Keep: 10, OlderThan: "7d", DryRun: true})
+}
+
+func TestNewPruneCmd_OkMinimum(t *testing.T) {
+	realCmd := NewPruneOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewPruneCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.Execute([]string{
+		"--namespace", "test-namespace",
+		"--service", "test-service",
+	})
+	cmd.ExpectReachesExecution()
+
+	DeepEqual(t, realCmd.ServiceFlags,
+		cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-service"})
+	DeepEqual(t, realCmd.PruneFlags, PruneFlags{Keep: 5})
+}
+
+func TestNewPruneCmd_RequiredFlags(t *testing.T) {
+	realCmd := NewPruneOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewPruneCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.Execute([]string{})
+	cmd.ExpectRequiredFlags([]string{"service"})
+}
+
+func TestPruneFlagsOlderThanDuration(t *testing.T) {
+	examples := []struct {
+		OlderThan   string
+		Expected    time.Duration
+		ExpectedErr string
+	}{
+		{"", 0, ""},
+		{"7d", 7 * 24 * time.Hour, ""},
+		{"36h", 36 * time.Hour, ""},
+		{"1h30m", 90 * time.Minute, ""},
+		{"xd", 0, "Expected age 'xd' to be a non-negative number of days (e.g. 7d)"},
+		{"-1d", 0, "Expected age '-1d' to be a non-negative number of days (e.g. 7d)"},
+		{"week", 0, "Expected age 'week' to be a non-negative duration (e.g. 36h, 7d)"},
+	}
+
+	for _, ex := range examples {
+		dur, err := (&PruneFlags{OlderThan: ex.OlderThan}).OlderThanDuration()
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected error '%s' but was '%v'", ex.ExpectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected no error: %s", err)
+		}
+		if dur != ex.Expected {
+			t.Fatalf("Expected duration '%s' but was '%s'", ex.Expected, dur)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+	"strings"
+
+	ctldiff "github.com/cppforlife/knctl/pkg/knctl/diff"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	buildv1alpha1 "github.com/knative/build/pkg/apis/build/v1alpha1"
+	buildclientset "github.com/knative/build/pkg/client/clientset/versioned"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	revisionScaleAnnotationPrefix = "autoscaling.knative.dev/"
+)
+
+var (
+	// Annotations that are expected to differ between any two revisions
+	revisionDiffIgnoredAnnotationPrefixes = []string{
+		"serving.knative.dev/",
+		ctlservice.RevisionTemplateHashAnnotationKey,
+		ctlservice.RevisionTemplateForceAnnotationKey,
+	}
+)
+
+// RevisionDiffFields are revision settings that are compared
+// (scale annotations are shown separately from other annotations)
+type RevisionDiffFields struct {
+	Image                string                   `json:"image"`
+	Env                  []corev1.EnvVar          `json:"env"`
+	EnvFrom              []corev1.EnvFromSource   `json:"envFrom"`
+	Annotations          map[string]string        `json:"annotations"`
+	Scale                map[string]string        `json:"scale"`
+	ServiceAccountName   string                   `json:"serviceAccountName"`
+	ConcurrencyModel     string                   `json:"concurrencyModel"`
+	ContainerConcurrency int64                    `json:"containerConcurrency"`
+	BuildName            string                   `json:"buildName"`
+	Build                *buildv1alpha1.BuildSpec `json:"build"`
+}
+
+type RevisionDiff struct {
+	oldRevision *v1alpha1.Revision
+	newRevision *v1alpha1.Revision
+	buildClient buildclientset.Interface
+}
+
+func NewRevisionDiff(oldRevision, newRevision *v1alpha1.Revision, buildClient buildclientset.Interface) RevisionDiff {
+	return RevisionDiff{oldRevision, newRevision, buildClient}
+}
+
+func (d RevisionDiff) Diff() (ctldiff.FieldDiff, error) {
+	oldFields, err := d.fields(d.oldRevision)
+	if err != nil {
+		return ctldiff.FieldDiff{}, err
+	}
+
+	newFields, err := d.fields(d.newRevision)
+	if err != nil {
+		return ctldiff.FieldDiff{}, err
+	}
+
+	return ctldiff.NewFieldDiff(oldFields, newFields)
+}
+
+func (d RevisionDiff) fields(revision *v1alpha1.Revision) (RevisionDiffFields, error) {
+	fields := RevisionDiffFields{
+		Image:                revision.Spec.Container.Image,
+		Env:                  revision.Spec.Container.Env,
+		EnvFrom:              revision.Spec.Container.EnvFrom,
+		Annotations:          map[string]string{},
+		Scale:                map[string]string{},
+		ServiceAccountName:   revision.Spec.ServiceAccountName,
+		ConcurrencyModel:     string(revision.Spec.ConcurrencyModel),
+		ContainerConcurrency: int64(revision.Spec.ContainerConcurrency),
+		BuildName:            revisionBuildName(revision),
+	}
+
+	for k, v := range revision.Annotations {
+		switch {
+		case strings.HasPrefix(k, revisionScaleAnnotationPrefix):
+			fields.Scale[strings.TrimPrefix(k, revisionScaleAnnotationPrefix)] = v
+		case !d.isIgnoredAnnotation(k):
+			fields.Annotations[k] = v
+		}
+	}
+
+	if len(fields.BuildName) > 0 {
+		build, err := d.buildClient.BuildV1alpha1().Builds(revision.Namespace).Get(fields.BuildName, metav1.GetOptions{})
+		if err != nil {
+			// Builds may be deleted independently of revisions
+			if !errors.IsNotFound(err) {
+				return RevisionDiffFields{}, fmt.Errorf("Getting build: %s", err)
+			}
+		} else {
+			fields.Build = &build.Spec
+		}
+	}
+
+	return fields, nil
+}
+
+func revisionBuildName(revision *v1alpha1.Revision) string {
+	if revision.Spec.BuildRef != nil {
+		return revision.Spec.BuildRef.Name
+	}
+	return revision.Spec.BuildName
+}
+
+func (RevisionDiff) isIgnoredAnnotation(key string) bool {
+	for _, prefix := range revisionDiffIgnoredAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
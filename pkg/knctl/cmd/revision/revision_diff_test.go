@@ -0,0 +1,105 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/revision"
+	ctldiff "github.com/cppforlife/knctl/pkg/knctl/diff"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRevisionDiff(t *testing.T) {
+	oldRevision := &v1alpha1.Revision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-service-00001",
+			Annotations: map[string]string{
+				"serving.knative.dev/lastPinned":       "1",
+				"cli.knative.dev/revisionTemplateHash": "hash1",
+				"autoscaling.knative.dev/minScale":     "1",
+				"team":                                 "a",
+			},
+		},
+		Spec: v1alpha1.RevisionSpec{
+			ServiceAccountName: "test-sa",
+			Container: corev1.Container{
+				Image: "test-image:1",
+				Env: []corev1.EnvVar{
+					{Name: "A", Value: "1"},
+					{Name: "B", ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "secret1"},
+							Key:                  "key1",
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	newRevision := &v1alpha1.Revision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-service-00002",
+			Annotations: map[string]string{
+				"serving.knative.dev/lastPinned":       "2",
+				"cli.knative.dev/revisionTemplateHash": "hash2",
+				"autoscaling.knative.dev/minScale":     "2",
+				"team":                                 "a",
+			},
+		},
+		Spec: v1alpha1.RevisionSpec{
+			ServiceAccountName:   "test-sa",
+			ContainerConcurrency: 5,
+			Container: corev1.Container{
+				Image: "test-image:2",
+				Env: []corev1.EnvVar{
+					{Name: "C", Value: "3"},
+					{Name: "B", ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "secret2"},
+							Key:                  "key1",
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	diff, err := NewRevisionDiff(oldRevision, newRevision, nil).Diff()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	expectedChanges := []ctldiff.FieldChange{
+		{Path: "containerConcurrency", Type: ctldiff.FieldChangeChanged, OldValue: "0", NewValue: "5"},
+		{Path: "env[A].name", Type: ctldiff.FieldChangeRemoved, OldValue: "A"},
+		{Path: "env[A].value", Type: ctldiff.FieldChangeRemoved, OldValue: "1"},
+		{Path: "env[B].valueFrom.secretKeyRef.name", Type: ctldiff.FieldChangeChanged, OldValue: "secret1", NewValue: "secret2"},
+		{Path: "env[C].name", Type: ctldiff.FieldChangeAdded, NewValue: "C"},
+		{Path: "env[C].value", Type: ctldiff.FieldChangeAdded, NewValue: "3"},
+		{Path: "image", Type: ctldiff.FieldChangeChanged, OldValue: "test-image:1", NewValue: "test-image:2"},
+		{Path: "scale.minScale", Type: ctldiff.FieldChangeChanged, OldValue: "1", NewValue: "2"},
+	}
+
+	if !reflect.DeepEqual(diff.Changes(), expectedChanges) {
+		t.Fatalf("Expected changes '%#v' to equal '%#v'", diff.Changes(), expectedChanges)
+	}
+}
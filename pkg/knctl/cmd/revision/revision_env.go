@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+type RevisionEnvVar struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// NewRevisionEnvVars describes environment variables of a container
+// including where their values come from (e.g. secrets, config maps)
+func NewRevisionEnvVars(container corev1.Container) []RevisionEnvVar {
+	var result []RevisionEnvVar
+
+	for _, envVar := range container.Env {
+		result = append(result, RevisionEnvVar{
+			Name:   envVar.Name,
+			Value:  envVar.Value,
+			Source: envVarSource(envVar.ValueFrom),
+		})
+	}
+
+	for _, envFrom := range container.EnvFrom {
+		var source string
+
+		switch {
+		case envFrom.SecretRef != nil:
+			source = fmt.Sprintf("All keys of secret '%s'", envFrom.SecretRef.Name)
+		case envFrom.ConfigMapRef != nil:
+			source = fmt.Sprintf("All keys of config map '%s'", envFrom.ConfigMapRef.Name)
+		}
+
+		if len(envFrom.Prefix) > 0 {
+			source += fmt.Sprintf(" (prefixed with '%s')", envFrom.Prefix)
+		}
+
+		result = append(result, RevisionEnvVar{Name: envFrom.Prefix + "*", Source: source})
+	}
+
+	return result
+}
+
+func envVarSource(valueFrom *corev1.EnvVarSource) string {
+	switch {
+	case valueFrom == nil:
+		return ""
+	case valueFrom.SecretKeyRef != nil:
+		return fmt.Sprintf("Secret '%s' key '%s'", valueFrom.SecretKeyRef.Name, valueFrom.SecretKeyRef.Key)
+	case valueFrom.ConfigMapKeyRef != nil:
+		return fmt.Sprintf("Config map '%s' key '%s'", valueFrom.ConfigMapKeyRef.Name, valueFrom.ConfigMapKeyRef.Key)
+	case valueFrom.FieldRef != nil:
+		return fmt.Sprintf("Field '%s'", valueFrom.FieldRef.FieldPath)
+	case valueFrom.ResourceFieldRef != nil:
+		return fmt.Sprintf("Resource '%s'", valueFrom.ResourceFieldRef.Resource)
+	default:
+		return ""
+	}
+}
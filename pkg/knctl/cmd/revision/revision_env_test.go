@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/revision"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNewRevisionEnvVars(t *testing.T) {
+	container := corev1.Container{
+		Env: []corev1.EnvVar{
+			{Name: "PLAIN", Value: "val"},
+			{Name: "FROM_SECRET", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "secret1"},
+					Key:                  "key1",
+				},
+			}},
+			{Name: "FROM_CONFIG_MAP", ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "cm1"},
+					Key:                  "key2",
+				},
+			}},
+			{Name: "FROM_FIELD", ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			}},
+		},
+		EnvFrom: []corev1.EnvFromSource{
+			{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "secret2"}}},
+			{Prefix: "CM_", ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "cm2"}}},
+		},
+	}
+
+	expected := []RevisionEnvVar{
+		{Name: "PLAIN", Value: "val"},
+		{Name: "FROM_SECRET", Source: "Secret 'secret1' key 'key1'"},
+		{Name: "FROM_CONFIG_MAP", Source: "Config map 'cm1' key 'key2'"},
+		{Name: "FROM_FIELD", Source: "Field 'metadata.name'"},
+		{Name: "*", Source: "All keys of secret 'secret2'"},
+		{Name: "CM_*", Source: "All keys of config map 'cm2' (prefixed with 'CM_')"},
+	}
+
+	envVars := NewRevisionEnvVars(container)
+
+	if !reflect.DeepEqual(envVars, expected) {
+		t.Fatalf("Expected env vars '%#v' to equal '%#v'", envVars, expected)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+type RevisionPruneDecision struct {
+	Revision v1alpha1.Revision
+	Delete   bool
+	Reason   string
+}
+
+// RevisionPrune decides which revisions of a service can be deleted.
+// Tagged revisions, revisions referenced by any route (desired or actual traffic)
+// or by service's release, and latest revisions of the service are never deleted.
+type RevisionPrune struct {
+	Keep      int
+	OlderThan time.Duration
+	Now       time.Time
+}
+
+func (p RevisionPrune) Decide(service v1alpha1.Service, revisions []v1alpha1.Revision,
+	routes []v1alpha1.Route, tags ctlservice.Tags) []RevisionPruneDecision {
+
+	revisions = append([]v1alpha1.Revision{}, revisions...)
+
+	// Latest first
+	ctlservice.SortRevisionsByGeneration(revisions)
+
+	revisionsWithTraffic := map[string]struct{}{}
+
+	for _, route := range routes {
+		// Desired targets may not be reflected in actual targets yet
+		for _, targets := range [][]v1alpha1.TrafficTarget{route.Spec.Traffic, route.Status.Traffic} {
+			for _, target := range targets {
+				if len(target.RevisionName) > 0 {
+					revisionsWithTraffic[target.RevisionName] = struct{}{}
+				}
+			}
+		}
+	}
+
+	revisionsInRelease := map[string]struct{}{}
+
+	if service.Spec.Release != nil {
+		for _, revisionName := range service.Spec.Release.Revisions {
+			revisionsInRelease[revisionName] = struct{}{}
+		}
+	}
+
+	var decisions []RevisionPruneDecision
+
+	for i, revision := range revisions {
+		decision := RevisionPruneDecision{Revision: revision}
+
+		_, hasTraffic := revisionsWithTraffic[revision.Name]
+		_, inRelease := revisionsInRelease[revision.Name]
+		revTags := tags.List(revision)
+		sort.Strings(revTags)
+
+		switch {
+		case len(revTags) > 0:
+			decision.Reason = fmt.Sprintf("Tagged '%s'", strings.Join(revTags, "', '"))
+		case hasTraffic:
+			decision.Reason = "Receives traffic"
+		case inRelease:
+			decision.Reason = "Part of release"
+		case revision.Name == service.Status.LatestCreatedRevisionName:
+			decision.Reason = "Latest created revision"
+		case revision.Name == service.Status.LatestReadyRevisionName:
+			decision.Reason = "Latest ready revision"
+		case i < p.Keep:
+			decision.Reason = fmt.Sprintf("Within %d most recent revisions", p.Keep)
+		case p.Now.Sub(revision.CreationTimestamp.Time) < p.OlderThan:
+			decision.Reason = fmt.Sprintf("Newer than %s", p.olderThanDesc())
+		default:
+			decision.Delete = true
+		}
+
+		decisions = append(decisions, decision)
+	}
+
+	return decisions
+}
+
+func (p RevisionPrune) olderThanDesc() string {
+	day := 24 * time.Hour
+	if p.OlderThan%day == 0 {
+		return fmt.Sprintf("%dd", p.OlderThan/day)
+	}
+	return p.OlderThan.String()
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/revision"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRevisionPruneDecide(t *testing.T) {
+	now := time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	revision := func(name string, age time.Duration, labels map[string]string) v1alpha1.Revision {
+		return v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Labels:            labels,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+		}
+	}
+
+	day := 24 * time.Hour
+
+	revisions := []v1alpha1.Revision{
+		revision("svc-00001", 30*day, map[string]string{"tag.cli.knative.dev/stable": "true"}),
+		revision("svc-00002", 20*day, nil),
+		revision("svc-00003", 10*day, nil),
+		revision("svc-00004", 6*day, nil),
+		revision("svc-00005", 5*day, nil),
+		revision("svc-00006", 4*day, nil),
+		revision("svc-00007", 3*day, nil),
+		revision("svc-00008", 2*day, nil),
+	}
+
+	service := v1alpha1.Service{
+		Status: v1alpha1.ServiceStatus{
+			LatestCreatedRevisionName: "svc-00008",
+			LatestReadyRevisionName:   "svc-00007",
+		},
+	}
+
+	routes := []v1alpha1.Route{{
+		Status: v1alpha1.RouteStatus{
+			Traffic: []v1alpha1.TrafficTarget{
+				{RevisionName: "svc-00002", Percent: 100},
+			},
+		},
+	}}
+
+	prune := RevisionPrune{Keep: 3, OlderThan: 5 * day, Now: now}
+	decisions := prune.Decide(service, revisions, routes, ctlservice.NewTags(nil))
+
+	var actual [][]interface{}
+
+	for _, decision := range decisions {
+		actual = append(actual, []interface{}{decision.Revision.Name, decision.Delete, decision.Reason})
+	}
+
+	expected := [][]interface{}{
+		{"svc-00008", false, "Latest created revision"},
+		{"svc-00007", false, "Latest ready revision"},
+		{"svc-00006", false, "Within 3 most recent revisions"},
+		{"svc-00005", true, ""},
+		{"svc-00004", true, ""},
+		{"svc-00003", true, ""},
+		{"svc-00002", false, "Receives traffic"},
+		{"svc-00001", false, "Tagged 'stable'"},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected decisions '%#v' to equal '%#v'", actual, expected)
+	}
+
+	prune = RevisionPrune{Keep: 0, OlderThan: 10 * day, Now: now}
+	decisions = prune.Decide(service, revisions, routes, ctlservice.NewTags(nil))
+
+	if decisions[3].Delete || decisions[3].Reason != "Newer than 10d" {
+		t.Fatalf("Expected newer revision to be kept but was '%#v'", decisions[3])
+	}
+
+	if !decisions[5].Delete {
+		t.Fatalf("Expected older revision to be deleted but was '%#v'", decisions[5])
+	}
+}
+
+func TestRevisionPruneDecideWithIdenticalTimestamps(t *testing.T) {
+	now := time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	revision := func(name, gen string) v1alpha1.Revision {
+		return v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Labels:            map[string]string{serving.ConfigurationGenerationAnnotationKey: gen},
+				CreationTimestamp: metav1.NewTime(now.Add(-30 * 24 * time.Hour)),
+			},
+		}
+	}
+
+	// Generated revision names do not sort the same way as generations
+	revisions := []v1alpha1.Revision{
+		revision("svc-zzzzz", "1"),
+		revision("svc-bbbbb", "5"),
+		revision("svc-ccccc", "2"),
+		revision("svc-yyyyy", "4"),
+		revision("svc-aaaaa", "3"),
+		revision("svc-xxxxx", "6"),
+	}
+
+	service := v1alpha1.Service{
+		Spec: v1alpha1.ServiceSpec{
+			Release: &v1alpha1.ReleaseType{Revisions: []string{"svc-ccccc", "svc-yyyyy"}},
+		},
+	}
+
+	// Desired traffic is not yet reflected in route status
+	routes := []v1alpha1.Route{{
+		Spec: v1alpha1.RouteSpec{
+			Traffic: []v1alpha1.TrafficTarget{
+				{RevisionName: "svc-zzzzz", Percent: 100},
+			},
+		},
+	}}
+
+	prune := RevisionPrune{Keep: 1, Now: now}
+	decisions := prune.Decide(service, revisions, routes, ctlservice.NewTags(nil))
+
+	var actual [][]interface{}
+
+	for _, decision := range decisions {
+		actual = append(actual, []interface{}{decision.Revision.Name, decision.Delete, decision.Reason})
+	}
+
+	expected := [][]interface{}{
+		{"svc-xxxxx", false, "Within 1 most recent revisions"},
+		{"svc-bbbbb", true, ""},
+		{"svc-yyyyy", false, "Part of release"},
+		{"svc-aaaaa", true, ""},
+		{"svc-ccccc", false, "Part of release"},
+		{"svc-zzzzz", false, "Receives traffic"},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected decisions '%#v' to equal '%#v'", actual, expected)
+	}
+}
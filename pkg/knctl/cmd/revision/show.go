@@ -19,15 +19,19 @@ package revision
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/cppforlife/go-cli-ui/ui"
 	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	cmdbld "github.com/cppforlife/knctl/pkg/knctl/cmd/build"
 	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
 	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
 	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type ShowOptions struct {
@@ -45,10 +49,18 @@ func NewShowCmd(o *ShowOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Comman
 	cmd := &cobra.Command{
 		Use:   "show",
 		Short: "Show revision",
-		Long:  "Show revision details in a namespace",
+		Long: `Show revision details in a namespace.
+
+Includes container image and its resolved digest, environment variables and their sources,
+scale and concurrency settings, associated build, conditions and pods.
+
+Tables are always printed in the same order (build table has no rows if revision does not have a build).`,
 		Example: `
   # Show details for revison 'rev1' in namespace 'ns1'
-  knctl revision show -r rev1 -n ns1`,
+  knctl revision show -r rev1 -n ns1
+
+  # Show image digest of revision tagged 'latest' for service 'svc1' in namespace 'ns1'
+  knctl revision show -r svc1:latest -n ns1 --json`,
 		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
 	}
 	o.RevisionFlags.Set(cmd, flagsFactory)
@@ -69,6 +81,12 @@ func (o *ShowOptions) Run() error {
 	}
 
 	o.printStatus(revision, tags)
+	o.printEnv(revision)
+
+	err = o.printBuild(revision)
+	if err != nil {
+		return err
+	}
 
 	cmdcore.NewConditionsTable(revision.Status.Conditions).Print(o.ui)
 
@@ -84,13 +102,18 @@ func (o *ShowOptions) Run() error {
 
 func (o *ShowOptions) printStatus(revision *v1alpha1.Revision, tags ctlservice.Tags) {
 	table := uitable.Table{
-		Title: fmt.Sprintf("Revision '%s'", o.RevisionFlags.Name),
-		// TODO Content: "revision",
+		Title:   fmt.Sprintf("Revision '%s'", o.RevisionFlags.Name),
+		Content: "revision",
 
 		Header: []uitable.Header{
 			uitable.NewHeader("Name"),
 			uitable.NewHeader("Tags"),
+			uitable.NewHeader("Image"),
 			uitable.NewHeader("Image digest"),
+			uitable.NewHeader("Service account"),
+			uitable.NewHeader("Concurrency model"),
+			uitable.NewHeader("Container concurrency"),
+			uitable.NewHeader("Scale"),
 			uitable.NewHeader("Log URL"),
 			uitable.NewHeader("Annotations"),
 			uitable.NewHeader("Age"),
@@ -99,18 +122,143 @@ func (o *ShowOptions) printStatus(revision *v1alpha1.Revision, tags ctlservice.T
 		Transpose: true,
 	}
 
+	scale := map[string]string{}
+	anns := map[string]string{}
+
+	// Scale annotations are shown separately
+	for k, v := range revision.Annotations {
+		if strings.HasPrefix(k, revisionScaleAnnotationPrefix) {
+			scale[strings.TrimPrefix(k, revisionScaleAnnotationPrefix)] = v
+		} else {
+			anns[k] = v
+		}
+	}
+
+	var containerConcurrencyVal uitable.Value = uitable.NewValueString("")
+
+	if revision.Spec.ContainerConcurrency > 0 {
+		containerConcurrencyVal = uitable.NewValueInt(int(revision.Spec.ContainerConcurrency))
+	}
+
 	table.Rows = append(table.Rows, []uitable.Value{
 		uitable.NewValueString(revision.Name),
 		uitable.NewValueStrings(tags.List(*revision)),
+		uitable.NewValueString(revision.Spec.Container.Image),
 		uitable.NewValueString(revision.Status.ImageDigest),
+		uitable.NewValueString(revision.Spec.ServiceAccountName),
+		uitable.NewValueString(string(revision.Spec.ConcurrencyModel)),
+		containerConcurrencyVal,
+		cmdcore.NewAnnotationsValue(scale),
 		uitable.NewValueString(strings.TrimSpace(revision.Status.LogURL)),
-		cmdcore.NewAnnotationsValue(revision.Annotations),
+		cmdcore.NewAnnotationsValue(anns),
 		cmdcore.NewValueAge(revision.CreationTimestamp.Time),
 	})
 
 	o.ui.PrintTable(table)
 }
 
+func (o *ShowOptions) printEnv(revision *v1alpha1.Revision) {
+	table := uitable.Table{
+		Title:   "Environment variables",
+		Content: "environment variables",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Name"),
+			uitable.NewHeader("Value"),
+			uitable.NewHeader("Source"),
+		},
+
+		SortBy: []uitable.ColumnSort{
+			{Column: 0, Asc: true},
+		},
+	}
+
+	for _, envVar := range NewRevisionEnvVars(revision.Spec.Container) {
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueString(envVar.Name),
+			uitable.NewValueString(envVar.Value),
+			uitable.NewValueString(envVar.Source),
+		})
+	}
+
+	o.ui.PrintTable(table)
+}
+
+// printBuild prints build table even if revision does not have a build
+// so that tables are always printed in the same order
+func (o *ShowOptions) printBuild(revision *v1alpha1.Revision) error {
+	buildName := revisionBuildName(revision)
+
+	table := uitable.Table{
+		Title:   "Build",
+		Content: "build",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Name"),
+			uitable.NewHeader("Started at"),
+			uitable.NewHeader("Completed at"),
+			uitable.NewHeader("Duration"),
+			uitable.NewHeader("Succeeded"),
+			uitable.NewHeader("Age"),
+		},
+
+		Transpose: true,
+	}
+
+	if len(buildName) == 0 {
+		o.ui.PrintTable(table)
+		return nil
+	}
+
+	table.Title = fmt.Sprintf("Build '%s'", buildName)
+
+	buildClient, err := o.depsFactory.BuildClient()
+	if err != nil {
+		return err
+	}
+
+	build, err := buildClient.BuildV1alpha1().Builds(revision.Namespace).Get(buildName, metav1.GetOptions{})
+	if err != nil {
+		// Builds may be deleted independently of revisions
+		if errors.IsNotFound(err) {
+			table.Rows = append(table.Rows, []uitable.Value{
+				uitable.NewValueString(buildName),
+				uitable.NewValueString(""),
+				uitable.NewValueString(""),
+				uitable.NewValueString(""),
+				uitable.NewValueFmt(uitable.NewValueString("Build not found"), true),
+				uitable.NewValueString(""),
+			})
+			o.ui.PrintTable(table)
+			return nil
+		}
+		return fmt.Errorf("Getting build: %s", err)
+	}
+
+	durationVal := uitable.NewValueString("")
+
+	if !build.Status.StartTime.IsZero() {
+		endTime := time.Now()
+		if !build.Status.CompletionTime.IsZero() {
+			endTime = build.Status.CompletionTime.Time
+		}
+		durationVal = uitable.NewValueString(endTime.Sub(build.Status.StartTime.Time).Round(time.Second).String())
+	}
+
+	table.Rows = append(table.Rows, []uitable.Value{
+		uitable.NewValueString(build.Name),
+		uitable.NewValueTime(build.Status.StartTime.Time),
+		uitable.NewValueTime(build.Status.CompletionTime.Time),
+		durationVal,
+		cmdbld.NewBuildSucceededValue(*build),
+		cmdcore.NewValueAge(build.CreationTimestamp.Time),
+	})
+
+	o.ui.PrintTable(table)
+
+	return nil
+}
+
 func (o *ShowOptions) setUpPodWatching(revision *v1alpha1.Revision) (chan corev1.Pod, error) {
 	podsToWatchCh := make(chan corev1.Pod)
 	cancelCh := make(chan struct{})
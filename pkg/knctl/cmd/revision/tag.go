@@ -17,11 +17,15 @@ limitations under the License.
 package revision
 
 import (
+	"fmt"
+
 	"github.com/cppforlife/go-cli-ui/ui"
 	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
 	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
 	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type TagOptions struct {
@@ -30,6 +34,10 @@ type TagOptions struct {
 
 	RevisionFlags cmdflags.RevisionFlags
 	TagFlags      cmdflags.TagFlags
+
+	ServiceName string
+	Undo        bool
+	Force       bool
 }
 
 func NewTagOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *TagOptions {
@@ -40,13 +48,32 @@ func NewTagCmd(o *TagOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command
 	cmd := &cobra.Command{
 		Use:   "tag",
 		Short: "Tag revision",
+		Long: `Tag revision.
+
+Each tag change is recorded in service's tag history (see 'knctl revision tag-history'), so it can be undone.
+Repeated '--undo' keeps walking back through the history (changes made by undo are skipped together with changes they undid).
+
+Protected tags (see 'knctl revision protect-tag') are not moved unless '--force' flag is specified.`,
 		Example: `
   # Tag revision 'rev1' in namespace 'ns1' as 'stable'
-  knctl revision tag -r rev1 -t stable -n ns1`,
+  knctl revision tag -r rev1 -t stable -n ns1
+
+  # Point tag 'stable' of service 'svc1' in namespace 'ns1' back at its previous revision
+  knctl revision tag -s svc1 -t stable --undo -n ns1`,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			// Revision is only optional when undoing tag changes
+			if !o.Undo {
+				return cmd.MarkFlagRequired("revision")
+			}
+			return nil
+		},
 		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
 	}
-	o.RevisionFlags.Set(cmd, flagsFactory)
+	o.RevisionFlags.SetOptional(cmd, flagsFactory)
 	o.TagFlags.Set(cmd, flagsFactory)
+	cmd.Flags().StringVarP(&o.ServiceName, "service", "s", "", "Specified service (used with --undo)")
+	cmd.Flags().BoolVar(&o.Undo, "undo", false, "Point tags back at revisions they were assigned to before last change")
+	cmd.Flags().BoolVar(&o.Force, "force", false, "Move tags even if they are protected")
 	return cmd
 }
 
@@ -56,7 +83,15 @@ func (o *TagOptions) Run() error {
 		return err
 	}
 
-	tags := ctlservice.NewTags(servingClient)
+	if o.Undo {
+		return o.undo(servingClient)
+	}
+
+	if len(o.RevisionFlags.Name) == 0 {
+		return fmt.Errorf("Expected revision to be specified via '--revision' flag")
+	}
+
+	tags := ctlservice.NewTags(servingClient).WithForce(o.Force)
 
 	revision, err := NewReference(o.RevisionFlags, tags, servingClient).Revision()
 	if err != nil {
@@ -72,3 +107,48 @@ func (o *TagOptions) Run() error {
 
 	return nil
 }
+
+func (o *TagOptions) undo(servingClient servingclientset.Interface) error {
+	if len(o.ServiceName) == 0 {
+		return fmt.Errorf("Expected service to be specified via '--service' flag when undoing tag changes")
+	}
+	if len(o.RevisionFlags.Name) > 0 {
+		return fmt.Errorf("Expected revision to not be specified when undoing tag changes")
+	}
+	if len(o.TagFlags.Tags) == 0 {
+		return fmt.Errorf("Expected at least one tag to be specified via '--tag' flag when undoing tag changes")
+	}
+
+	namespace := o.RevisionFlags.NamespaceFlags.Name
+	tags := ctlservice.NewTags(servingClient).WithForce(o.Force)
+	history := ctlservice.NewTagHistory(servingClient, namespace, o.ServiceName)
+
+	for _, tag := range o.TagFlags.Tags {
+		entry, found, err := history.LastUndoable(tag)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return fmt.Errorf("Expected tag '%s' to have changes that can be undone for service '%s'", tag, o.ServiceName)
+		}
+
+		if len(entry.PreviousRevisionName) == 0 {
+			return fmt.Errorf("Expected tag '%s' to have been assigned to a revision before revision '%s'", tag, entry.RevisionName)
+		}
+
+		prevRevision, err := servingClient.ServingV1alpha1().Revisions(namespace).Get(entry.PreviousRevisionName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("Getting revision: %s", err)
+		}
+
+		err = tags.RepointUndo(prevRevision, tag)
+		if err != nil {
+			return err
+		}
+
+		o.ui.PrintLinef("Tag '%s' points to revision '%s' (was '%s')", tag, prevRevision.Name, entry.RevisionName)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/spf13/cobra"
+)
+
+type TagHistoryOptions struct {
+	ui          ui.UI
+	depsFactory cmdcore.DepsFactory
+
+	ServiceFlags cmdflags.ServiceFlags
+	Tag          string
+}
+
+func NewTagHistoryOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *TagHistoryOptions {
+	return &TagHistoryOptions{ui: ui, depsFactory: depsFactory}
+}
+
+func NewTagHistoryCmd(o *TagHistoryOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tag-history",
+		Short: "Show tag history",
+		Long: fmt.Sprintf(`Show tag history of a service.
+
+Up to %d most recent tag changes are recorded in '%s' annotation on the service.`,
+			ctlservice.TagHistoryMaxEntries, ctlservice.TagHistoryAnnotationKey),
+		Example: `
+  # Show history of tag 'latest' for service 'svc1' in namespace 'ns1'
+  knctl revision tag-history -s svc1 -t latest -n ns1`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	o.ServiceFlags.Set(cmd, flagsFactory)
+	cmd.Flags().StringVarP(&o.Tag, "tag", "t", "", "Show history only for specified tag")
+	return cmd
+}
+
+func (o *TagHistoryOptions) Run() error {
+	servingClient, err := o.depsFactory.ServingClient()
+	if err != nil {
+		return err
+	}
+
+	entries, err := ctlservice.NewTagHistory(servingClient, o.ServiceFlags.NamespaceFlags.Name, o.ServiceFlags.Name).List()
+	if err != nil {
+		return err
+	}
+
+	table := uitable.Table{
+		Title:   fmt.Sprintf("Tag history for service '%s'", o.ServiceFlags.Name),
+		Content: "tag changes",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Tag"),
+			uitable.NewHeader("Revision"),
+			uitable.NewHeader("Previous revision"),
+			uitable.NewHeader("Undo"),
+			uitable.NewHeader("Local user"),
+			uitable.NewHeader("Time"),
+			uitable.NewHeader("Age"),
+		},
+	}
+
+	// Show latest first
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+
+		if len(o.Tag) > 0 && entry.Tag != o.Tag {
+			continue
+		}
+
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueString(entry.Tag),
+			uitable.NewValueString(entry.RevisionName),
+			uitable.NewValueString(entry.PreviousRevisionName),
+			uitable.NewValueBool(entry.Undo),
+			uitable.NewValueString(entry.LocalUser),
+			uitable.NewValueTime(entry.Time),
+			cmdcore.NewValueAge(entry.Time),
+		})
+	}
+
+	o.ui.PrintTable(table)
+
+	return nil
+}
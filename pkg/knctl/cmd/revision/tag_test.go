@@ -32,11 +32,16 @@ func TestNewTagCmd_Ok(t *testing.T) {
 	cmd.Execute([]string{
 		"-n", "test-namespace",
 		"-r", "test-revision",
+		"-t", "stable",
 	})
 	cmd.ExpectReachesExecution()
 
 	DeepEqual(t, realCmd.RevisionFlags,
 		cmdflags.RevisionFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-revision"})
+	DeepEqual(t, realCmd.TagFlags, cmdflags.TagFlags{[]string{"stable"}})
+	DeepEqual(t, realCmd.ServiceName, "")
+	DeepEqual(t, realCmd.Undo, false)
+	DeepEqual(t, realCmd.Force, false)
 }
 
 func TestNewTagCmd_OkLongFlagNames(t *testing.T) {
@@ -52,9 +57,36 @@ func TestNewTagCmd_OkLongFlagNames(t *testing.T) {
 		cmdflags.RevisionFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-revision"})
 }
 
+func TestNewTagCmd_OkUndo(t *testing.T) {
+	realCmd := NewTagOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewTagCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.Execute([]string{
+		"-n", "test-namespace",
+		"-s", "test-service",
+		"-t", "stable",
+		"--undo",
+		"--force",
+	})
+	cmd.ExpectReachesExecution()
+
+	DeepEqual(t, realCmd.RevisionFlags,
+		cmdflags.RevisionFlags{cmdcore.NamespaceFlags{"test-namespace"}, ""})
+	DeepEqual(t, realCmd.TagFlags, cmdflags.TagFlags{[]string{"stable"}})
+	DeepEqual(t, realCmd.ServiceName, "test-service")
+	DeepEqual(t, realCmd.Undo, true)
+	DeepEqual(t, realCmd.Force, true)
+}
+
 func TestNewTagCmd_RequiredFlags(t *testing.T) {
 	realCmd := NewTagOptions(nil, cmdcore.NewDepsFactory())
 	cmd := NewTestCmd(t, NewTagCmd(realCmd, cmdcore.FlagsFactory{}))
 	cmd.Execute([]string{})
 	cmd.ExpectRequiredFlags([]string{"revision"})
 }
+
+func TestNewTagCmd_OkUndoMinimum(t *testing.T) {
+	realCmd := NewTagOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewTagCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.Execute([]string{"--undo"})
+	cmd.ExpectReachesExecution()
+}
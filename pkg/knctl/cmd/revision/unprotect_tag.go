@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/spf13/cobra"
+)
+
+type UnprotectTagOptions struct {
+	ui          ui.UI
+	depsFactory cmdcore.DepsFactory
+
+	ServiceFlags cmdflags.ServiceFlags
+	TagFlags     cmdflags.TagFlags
+}
+
+func NewUnprotectTagOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *UnprotectTagOptions {
+	return &UnprotectTagOptions{ui: ui, depsFactory: depsFactory}
+}
+
+func NewUnprotectTagCmd(o *UnprotectTagOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unprotect-tag",
+		Short: "Remove tag protection",
+		Long: fmt.Sprintf(`Remove protection from tags of a service.
+
+Protected tags are listed in '%s' annotation on the service.`, ctlservice.TagProtectionAnnotationKey),
+		Example: `
+  # Allow tag 'prod' of service 'svc1' in namespace 'ns1' to be moved
+  knctl revision unprotect-tag -s svc1 -t prod -n ns1`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	o.ServiceFlags.Set(cmd, flagsFactory)
+	o.TagFlags.Set(cmd, flagsFactory)
+	return cmd
+}
+
+func (o *UnprotectTagOptions) Run() error {
+	if len(o.TagFlags.Tags) == 0 {
+		return fmt.Errorf("Expected at least one tag to be specified via '--tag' flag")
+	}
+
+	servingClient, err := o.depsFactory.ServingClient()
+	if err != nil {
+		return err
+	}
+
+	protection := ctlservice.NewTagProtection(servingClient, o.ServiceFlags.NamespaceFlags.Name, o.ServiceFlags.Name)
+
+	err = protection.Unprotect(o.TagFlags.Tags)
+	if err != nil {
+		return err
+	}
+
+	protectedTags, err := protection.List()
+	if err != nil {
+		return err
+	}
+
+	o.ui.PrintLinef("Protected tags of service '%s': %s", o.ServiceFlags.Name, strings.Join(protectedTags, ", "))
+
+	return nil
+}
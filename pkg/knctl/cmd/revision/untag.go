@@ -30,6 +30,7 @@ type UntagOptions struct {
 
 	RevisionFlags cmdflags.RevisionFlags
 	TagFlags      cmdflags.TagFlags
+	Force         bool
 }
 
 func NewUntagOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *UntagOptions {
@@ -47,6 +48,7 @@ func NewUntagCmd(o *UntagOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Comm
 	}
 	o.RevisionFlags.Set(cmd, flagsFactory)
 	o.TagFlags.Set(cmd, flagsFactory)
+	cmd.Flags().BoolVar(&o.Force, "force", false, "Remove tags even if they are protected")
 	return cmd
 }
 
@@ -56,7 +58,7 @@ func (o *UntagOptions) Run() error {
 		return err
 	}
 
-	tags := ctlservice.NewTags(servingClient)
+	tags := ctlservice.NewTags(servingClient).WithForce(o.Force)
 
 	revision, err := NewReference(o.RevisionFlags, tags, servingClient).Revision()
 	if err != nil {
@@ -32,11 +32,13 @@ func TestNewUntagCmd_Ok(t *testing.T) {
 	cmd.Execute([]string{
 		"-n", "test-namespace",
 		"-r", "test-revision",
+		"--force",
 	})
 	cmd.ExpectReachesExecution()
 
 	DeepEqual(t, realCmd.RevisionFlags,
 		cmdflags.RevisionFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-revision"})
+	DeepEqual(t, realCmd.Force, true)
 }
 
 func TestNewUntagCmd_OkLongFlagNames(t *testing.T) {
@@ -27,12 +27,12 @@ import (
 	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
 	cmdrev "github.com/cppforlife/knctl/pkg/knctl/cmd/revision"
 	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
-	"github.com/cppforlife/knctl/pkg/knctl/util"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 type CreateOptions struct {
@@ -41,6 +41,11 @@ type CreateOptions struct {
 
 	RouteFlags   RouteFlags
 	TrafficFlags TrafficFlags
+
+	ConvertToManual bool
+	DryRun          bool
+	Wait            bool
+	WaitTimeout     time.Duration
 }
 
 func NewCreateOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *CreateOptions {
@@ -53,13 +58,29 @@ func NewCreateCmd(o *CreateOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Co
 		Short: "Create or update route",
 		Long: `Create or update route with traffic percentages.
 
-If route was automatically created for a service, service must be deployed with '--managed-route=false' flag on all subsequent deploys.`,
+Traffic percentages must add up to 100%. Each target may be given a name (e.g. 'svc1:latest=0%@candidate')
+to be reachable at '{name}.{route domain}' regardless of its traffic percentage. Revision references (e.g. 'svc1:latest') are resolved before route is updated.
+
+Services that own the route or revisions receiving traffic must not manage their routes: they must be deployed
+with '--managed-route=false' flag on all subsequent deploys, or converted with '--convert-to-manual' flag.`,
 		Example: `
   # Set traffic percentages for service 'svc1' in namespace 'ns1'
   knctl rollout --route rt1 -p svc1:latest=20% -p svc1:previous=80% -n ns1
 
   # Roll back traffic for previous revision of service 'svc1' in namespace 'ns1'
-  knctl rollout --route rt1 -p svc1:previous=100% -n ns1`,
+  knctl rollout --route rt1 -p svc1:previous=100% -n ns1
+
+  # Send all traffic to previous revision and expose latest revision at 'candidate.{route domain}' without traffic
+  knctl rollout --route rt1 -p svc1:previous=100% -p svc1:latest=0%@candidate -n ns1
+
+  # Split traffic of route 'rt1' between services 'svc1' and 'svc2', converting them to not manage their routes
+  knctl rollout --route rt1 -p svc1:latest=50% -p svc2:latest=50% --convert-to-manual -n ns1
+
+  # Show how traffic would change without updating route 'rt1' in namespace 'ns1'
+  knctl rollout --route rt1 -p svc1:latest=50% -p svc1:previous=50% --dry-run -n ns1
+
+  # Set traffic percentages and wait for route 'rt1' in namespace 'ns1' to apply them
+  knctl rollout --route rt1 -p svc1:latest=100% --wait -n ns1`,
 		Annotations: map[string]string{
 			cmdcore.RouteMgmtHelpGroup.Key: cmdcore.RouteMgmtHelpGroup.Value,
 		},
@@ -67,6 +88,10 @@ If route was automatically created for a service, service must be deployed with
 	}
 	o.RouteFlags.Set(cmd, flagsFactory)
 	o.TrafficFlags.Set(cmd, flagsFactory)
+	cmd.Flags().BoolVar(&o.ConvertToManual, "convert-to-manual", false, "Convert associated services to not manage their routes (keeps current traffic)")
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "Show traffic changes against current route without updating it")
+	cmd.Flags().BoolVar(&o.Wait, "wait", false, "Wait for route to become ready with new traffic")
+	cmd.Flags().DurationVar(&o.WaitTimeout, "wait-timeout", 5*time.Minute, "Set timeout for waiting for route to become ready")
 	return cmd
 }
 
@@ -76,122 +101,171 @@ func (o *CreateOptions) Run() error {
 		return err
 	}
 
-	tags := ctlservice.NewTags(servingClient)
+	targets, err := o.targets(servingClient)
+	if err != nil {
+		return err
+	}
+
+	err = ValidateTrafficTargets(targets)
+	if err != nil {
+		return err
+	}
+
+	routeServices := NewRouteServices(servingClient, o.RouteFlags.NamespaceFlags.Name)
+
+	if o.DryRun {
+		return o.dryRun(servingClient, routeServices, targets)
+	}
+
+	convertedNames, err := routeServices.EnsureUnmanaged(o.RouteFlags.Name, targets, o.ConvertToManual)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range convertedNames {
+		o.ui.PrintLinef("Converted service '%s' to not manage its route", name)
+	}
 
 	route := &v1alpha1.Route{
 		ObjectMeta: o.TrafficFlags.GenerateNameFlags.Apply(metav1.ObjectMeta{
 			Name:      o.RouteFlags.Name,
 			Namespace: o.RouteFlags.NamespaceFlags.Name,
 		}),
+		Spec: v1alpha1.RouteSpec{Traffic: targets},
 	}
 
+	savedRoute, err := o.createOrUpdate(servingClient, route)
+	if err != nil {
+		return err
+	}
+
+	if o.Wait {
+		return waitForRouteTraffic(servingClient, savedRoute, o.WaitTimeout, o.ui)
+	}
+
+	return nil
+}
+
+func (o *CreateOptions) targets(servingClient servingclientset.Interface) ([]v1alpha1.TrafficTarget, error) {
+	tags := ctlservice.NewTags(servingClient)
+
 	var targets []v1alpha1.TrafficTarget
 
 	for _, traffic := range o.TrafficFlags.RevisionPercentages {
-		name, percent, err := o.extractNameAndPercentage(traffic)
+		name, percent, targetName, err := o.extractNameAndPercentage(traffic)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		revFlags := cmdflags.RevisionFlags{Name: name, NamespaceFlags: o.RouteFlags.NamespaceFlags}
 
 		revision, err := cmdrev.NewReference(revFlags, tags, servingClient).Revision()
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("Resolving revision '%s': %s", name, err)
 		}
 
 		targets = append(targets, v1alpha1.TrafficTarget{
+			Name:         targetName,
 			RevisionName: revision.Name,
 			Percent:      percent,
 		})
 	}
 
 	for _, traffic := range o.TrafficFlags.ServicePercentages {
-		name, percent, err := o.extractNameAndPercentage(traffic)
+		name, percent, targetName, err := o.extractNameAndPercentage(traffic)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		targets = append(targets, v1alpha1.TrafficTarget{
+			Name:              targetName,
 			ConfigurationName: name,
 			Percent:           percent,
 		})
 	}
 
-	route.Spec.Traffic = targets
-
-	err = o.ensureUnmanagedRouteOnService(servingClient)
-	if err != nil {
-		return err
-	}
-
-	return o.createOrUpdate(servingClient, route)
+	return targets, nil
 }
 
-func (o *CreateOptions) extractNameAndPercentage(str string) (string, int, error) {
+func (o *CreateOptions) extractNameAndPercentage(str string) (string, int, string, error) {
 	pieces := strings.SplitN(str, "=", 2)
 	if len(pieces) != 2 {
-		return "", 0, fmt.Errorf("Expected percentage to be in format 'service=percentage'")
+		return "", 0, "", fmt.Errorf("Expected percentage to be in format 'service=percentage'")
 	}
 
-	percent, err := strconv.Atoi(strings.TrimSuffix(pieces[1], "%"))
+	// Optional target name exposes target at '{name}.{route domain}'
+	percentPieces := strings.SplitN(pieces[1], "@", 2)
+
+	percent, err := strconv.Atoi(strings.TrimSuffix(percentPieces[0], "%"))
 	if err != nil {
-		return "", 0, fmt.Errorf("Expected percentage value to be an integer")
+		return "", 0, "", fmt.Errorf("Expected percentage value to be an integer")
 	}
 
 	if percent < 0 || percent > 100 {
-		return "", 0, fmt.Errorf("Expected percentage value to be between 0%% and 100%%")
+		return "", 0, "", fmt.Errorf("Expected percentage value to be between 0%% and 100%%")
+	}
+
+	var targetName string
+
+	if len(percentPieces) == 2 {
+		targetName = percentPieces[1]
+
+		if errs := validation.IsDNS1123Label(targetName); len(errs) > 0 {
+			return "", 0, "", fmt.Errorf("Expected target name '%s' to be a valid DNS label: %s", targetName, strings.Join(errs, ", "))
+		}
 	}
 
-	return pieces[0], percent, nil
+	return pieces[0], percent, targetName, nil
 }
 
-func (o *CreateOptions) ensureUnmanagedRouteOnService(servingClient servingclientset.Interface) error {
-	// Assumes that service has the same name as the route
-	// TODO this may not be a proper assumption to make
-	service, err := servingClient.ServingV1alpha1().Services(o.RouteFlags.NamespaceFlags.Name).Get(o.RouteFlags.Name, metav1.GetOptions{})
+func (o *CreateOptions) dryRun(servingClient servingclientset.Interface, routeServices RouteServices, targets []v1alpha1.TrafficTarget) error {
+	managedNames, err := routeServices.ManagedNames(o.RouteFlags.Name, targets)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			return nil
+		return err
+	}
+
+	if len(managedNames) > 0 && !o.ConvertToManual {
+		return NewManagedRouteServicesErr(managedNames)
+	}
+
+	var currTargets []v1alpha1.TrafficTarget
+
+	route, err := servingClient.ServingV1alpha1().Routes(o.RouteFlags.NamespaceFlags.Name).Get(o.RouteFlags.Name, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("Getting route: %s", err)
 		}
-		return fmt.Errorf("Getting associated service: %s", err)
+	} else {
+		currTargets = route.Status.Traffic
 	}
 
-	if service.Spec.Manual == nil {
-		hintMsg := "(use `--managed-route=false` flag when running `deploy` command)"
-		return fmt.Errorf("Expected associated service '%s' to not manage route %s", o.RouteFlags.Name, hintMsg)
+	NewTrafficChangesTable(currTargets, targets).Print(o.ui)
+
+	for _, name := range managedNames {
+		o.ui.PrintLinef("Service '%s' would be converted to not manage its route (dry run)", name)
 	}
 
+	o.ui.PrintLinef("Route '%s' was not updated (dry run)", o.RouteFlags.Name)
+
 	return nil
 }
 
-func (o *CreateOptions) createOrUpdate(servingClient servingclientset.Interface, route *v1alpha1.Route) error {
-	_, createErr := servingClient.ServingV1alpha1().Routes(o.RouteFlags.NamespaceFlags.Name).Create(route)
+func (o *CreateOptions) createOrUpdate(servingClient servingclientset.Interface, route *v1alpha1.Route) (*v1alpha1.Route, error) {
+	createdRoute, createErr := servingClient.ServingV1alpha1().Routes(o.RouteFlags.NamespaceFlags.Name).Create(route)
 	if createErr != nil {
 		if errors.IsAlreadyExists(createErr) {
 			return o.update(servingClient, route)
 		}
 
-		return fmt.Errorf("Creating route: %s", createErr)
+		return nil, fmt.Errorf("Creating route: %s", createErr)
 	}
 
-	return nil
+	return createdRoute, nil
 }
 
-func (o *CreateOptions) update(servingClient servingclientset.Interface, route *v1alpha1.Route) error {
-	return util.Retry(time.Second, 10*time.Second, func() (bool, error) {
-		origRoute, err := servingClient.ServingV1alpha1().Routes(o.RouteFlags.NamespaceFlags.Name).Get(o.RouteFlags.Name, metav1.GetOptions{})
-		if err != nil {
-			return true, err
-		}
-
+func (o *CreateOptions) update(servingClient servingclientset.Interface, route *v1alpha1.Route) (*v1alpha1.Route, error) {
+	return updateRoute(servingClient, o.RouteFlags.NamespaceFlags.Name, o.RouteFlags.Name, func(origRoute *v1alpha1.Route) error {
 		origRoute.Spec = route.Spec
-
-		_, err = servingClient.ServingV1alpha1().Routes(o.RouteFlags.NamespaceFlags.Name).Update(origRoute)
-		if err != nil {
-			return false, fmt.Errorf("Updating route: %s", err)
-		}
-
-		return true, nil
+		return nil
 	})
 }
@@ -18,6 +18,7 @@ package route_test
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/cppforlife/knctl/pkg/knctl/cmd"
 	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
@@ -35,6 +36,10 @@ func TestNewCreateCmd_Ok(t *testing.T) {
 		"-p", "srv1:rev2=25%",
 		"--service-percentage", "srv1=25%",
 		"--service-percentage", "srv2=25%",
+		"--convert-to-manual",
+		"--dry-run",
+		"--wait",
+		"--wait-timeout", "1m",
 	})
 	cmd.ExpectReachesExecution()
 
@@ -45,6 +50,11 @@ func TestNewCreateCmd_Ok(t *testing.T) {
 		RevisionPercentages: []string{"srv1:rev1=25%", "srv1:rev2=25%"},
 		ServicePercentages:  []string{"srv1=25%", "srv2=25%"},
 	})
+
+	DeepEqual(t, realCmd.ConvertToManual, true)
+	DeepEqual(t, realCmd.DryRun, true)
+	DeepEqual(t, realCmd.Wait, true)
+	DeepEqual(t, realCmd.WaitTimeout, time.Minute)
 }
 
 func TestNewCreateCmd_OkLongFlagNames(t *testing.T) {
@@ -83,6 +93,11 @@ func TestNewCreateCmd_OkMinimum(t *testing.T) {
 		RouteFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-route"})
 
 	DeepEqual(t, realCmd.TrafficFlags, TrafficFlags{RevisionPercentages: nil})
+
+	DeepEqual(t, realCmd.ConvertToManual, false)
+	DeepEqual(t, realCmd.DryRun, false)
+	DeepEqual(t, realCmd.Wait, false)
+	DeepEqual(t, realCmd.WaitTimeout, 5*time.Minute)
 }
 
 func TestNewCreateCmd_RequiredFlags(t *testing.T) {
@@ -17,12 +17,10 @@ limitations under the License.
 package route
 
 import (
-	"fmt"
-	"os/exec"
-	"strings"
-
 	"github.com/cppforlife/go-cli-ui/ui"
 	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	ctlcurl "github.com/cppforlife/knctl/pkg/knctl/curl"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -31,9 +29,11 @@ type CurlOptions struct {
 	ui          ui.UI
 	depsFactory cmdcore.DepsFactory
 
-	RouteFlags RouteFlags
-	CurlFlags  CurlFlags
-	Verbose    bool
+	RouteFlags       RouteFlags
+	CurlFlags        CurlFlags
+	HTTPRequestFlags cmdflags.HTTPRequestFlags
+	Target           string
+	Verbose          bool
 }
 
 func NewCurlOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *CurlOptions {
@@ -44,45 +44,37 @@ func NewCurlCmd(o *CurlOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Comman
 	cmd := &cobra.Command{
 		Use:   "curl",
 		Short: "Curl route",
-		Long: `Send a HTTP request to the first ingress address with the Host header set to the service's domain.
-
-Requires 'curl' command installed on the system.`,
+		Long:  `Send a HTTP request to the first ingress address with the Host header set to the route's domain.`,
 		Example: `
   # Curl route 'rt1' in namespace 'ns1'
-  knctl route curl --route rt1 -n ns1`,
+  knctl route curl --route rt1 -n ns1
+
+  # Curl traffic target named 'candidate' of route 'rt1' in namespace 'ns1'
+  knctl route curl --route rt1 --target candidate -n ns1`,
 		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
 	}
 	o.RouteFlags.Set(cmd, flagsFactory)
 	o.CurlFlags.Set(cmd, flagsFactory)
-	cmd.Flags().BoolVarP(&o.Verbose, "verbose", "v", false, "Makes curl verbose during the operation")
+	o.HTTPRequestFlags.Set(cmd, flagsFactory)
+	cmd.Flags().StringVar(&o.Target, "target", "", "Set traffic target name to send request to")
+	cmd.Flags().BoolVarP(&o.Verbose, "verbose", "v", false, "Print request and response details")
 	return cmd
 }
 
 func (o *CurlOptions) Run() error {
-	domain, url, err := o.addr()
+	opts, err := o.HTTPRequestFlags.RequestOpts()
 	if err != nil {
 		return err
 	}
 
-	cmdName := "curl"
-	cmdArgs := []string{}
-
-	if o.Verbose {
-		cmdArgs = append(cmdArgs, "-vvv")
-	}
-
-	cmdArgs = append(cmdArgs, []string{"-sS", "-H", "Host: " + domain, url}...)
+	opts.Verbose = o.Verbose
 
-	o.ui.PrintLinef("Running: %s '%s'", cmdName, strings.Join(cmdArgs, "' '"))
-
-	out, err := exec.Command(cmdName, cmdArgs...).CombinedOutput()
+	domain, url, err := o.addr()
 	if err != nil {
-		return fmt.Errorf("Running curl: %s", err)
+		return err
 	}
 
-	o.ui.PrintBlock(out)
-
-	return nil
+	return ctlcurl.NewRequest(url, domain, opts).Run(o.ui)
 }
 
 func (o *CurlOptions) addr() (string, string, error) {
@@ -108,6 +100,13 @@ func (o *CurlOptions) addr() (string, string, error) {
 		return "", "", err
 	}
 
+	if len(o.Target) > 0 {
+		domain, err = routeAddr.TargetDomain(o.Target)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
 	url, err := routeAddr.URL(o.CurlFlags.Port, false)
 	if err != nil {
 		return "", "", err
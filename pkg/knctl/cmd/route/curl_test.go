@@ -18,9 +18,11 @@ package route_test
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/cppforlife/knctl/pkg/knctl/cmd"
 	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
 	. "github.com/cppforlife/knctl/pkg/knctl/cmd/route"
 )
 
@@ -32,6 +34,13 @@ func TestNewCurlCmd_Ok(t *testing.T) {
 		"-n", "test-namespace",
 		"--route", "test-route",
 		"-p", "1234",
+		"-X", "PUT",
+		"-H", "X-A: 1",
+		"-H", "Accept: text/html, application/json",
+		"-d", "test-data",
+		"-i",
+		"--expect-status", "201",
+		"--timeout", "5s",
 		"-v",
 	})
 	cmd.ExpectReachesExecution()
@@ -39,6 +48,14 @@ func TestNewCurlCmd_Ok(t *testing.T) {
 	DeepEqual(t, realCmd.RouteFlags,
 		RouteFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-route"})
 	DeepEqual(t, realCmd.CurlFlags, CurlFlags{Port: int32(1234)})
+	DeepEqual(t, realCmd.HTTPRequestFlags, cmdflags.HTTPRequestFlags{
+		Method:       "PUT",
+		Headers:      []string{"X-A: 1", "Accept: text/html, application/json"},
+		Data:         "test-data",
+		Timeout:      5 * time.Second,
+		Include:      true,
+		ExpectStatus: 201,
+	})
 	DeepEqual(t, realCmd.Verbose, true)
 }
 
@@ -49,6 +66,11 @@ func TestNewCurlCmd_OkLongFlagNames(t *testing.T) {
 		"--namespace", "test-namespace",
 		"--route", "test-route",
 		"--port", "1234",
+		"--request", "PUT",
+		"--header", "X-A: 1",
+		"--data-file", "/tmp/data",
+		"--include",
+		"--target", "candidate",
 		"--verbose",
 	})
 	cmd.ExpectReachesExecution()
@@ -56,6 +78,14 @@ func TestNewCurlCmd_OkLongFlagNames(t *testing.T) {
 	DeepEqual(t, realCmd.RouteFlags,
 		RouteFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-route"})
 	DeepEqual(t, realCmd.CurlFlags, CurlFlags{Port: int32(1234)})
+	DeepEqual(t, realCmd.HTTPRequestFlags, cmdflags.HTTPRequestFlags{
+		Method:   "PUT",
+		Headers:  []string{"X-A: 1"},
+		DataFile: "/tmp/data",
+		Timeout:  30 * time.Second,
+		Include:  true,
+	})
+	DeepEqual(t, realCmd.Target, "candidate")
 	DeepEqual(t, realCmd.Verbose, true)
 }
 
@@ -71,6 +101,7 @@ func TestNewCurlCmd_OkMinimum(t *testing.T) {
 	DeepEqual(t, realCmd.RouteFlags,
 		RouteFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-route"})
 	DeepEqual(t, realCmd.CurlFlags, CurlFlags{Port: int32(80)})
+	DeepEqual(t, realCmd.HTTPRequestFlags, cmdflags.HTTPRequestFlags{Timeout: 30 * time.Second})
 	DeepEqual(t, realCmd.Verbose, false)
 }
 
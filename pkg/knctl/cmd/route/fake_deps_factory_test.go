@@ -0,0 +1,32 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route_test
+
+import (
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+)
+
+// fakeDepsFactory only provides serving client
+type fakeDepsFactory struct {
+	cmdcore.DepsFactory
+	servingClient servingclientset.Interface
+}
+
+func (f fakeDepsFactory) ServingClient() (servingclientset.Interface, error) {
+	return f.servingClient, nil
+}
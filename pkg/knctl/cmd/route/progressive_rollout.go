@@ -0,0 +1,283 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	cmdrev "github.com/cppforlife/knctl/pkg/knctl/cmd/revision"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	progressiveRolloutProbeRequests = 5
+	progressiveRolloutProbeTimeout  = 10 * time.Second
+)
+
+type ProgressiveRolloutOptions struct {
+	ui          ui.UI
+	depsFactory cmdcore.DepsFactory
+
+	RouteFlags              RouteFlags
+	ProgressiveRolloutFlags ProgressiveRolloutFlags
+	ConvertToManual         bool
+}
+
+func NewProgressiveRolloutOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *ProgressiveRolloutOptions {
+	return &ProgressiveRolloutOptions{ui: ui, depsFactory: depsFactory}
+}
+
+func NewProgressiveRolloutCmd(o *ProgressiveRolloutOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "progressive",
+		Short: "Progressively shift route traffic to a revision",
+		Long: `Progressively shift route traffic from one revision to another.
+
+At each step traffic percentage sent to the new revision is increased, and after waiting for specified interval
+new revision is checked to be ready and probed with HTTP requests through the ingress. Probe requests are sent
+to the route's traffic target named after the new revision ({revision}.{route domain}) so that they reach only the new revision.
+If any check fails, original route traffic is restored.
+
+Rollout progress is recorded on the route so that an interrupted rollout continues from the last step when command is run again
+(with the same '--steps' and '--interval' flags).
+
+Services that own the route or revisions receiving traffic must not manage their routes
+(deploy them with '--managed-route=false' flag, or convert them with '--convert-to-manual' flag).`,
+		Example: `
+  # Progressively shift traffic from previous to latest revision of service 'svc1' in namespace 'ns1'
+  knctl rollout progressive --route rt1 --from svc1:previous --to svc1:latest --steps 5,25,50,100 --interval 2m -n ns1`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	o.RouteFlags.Set(cmd, flagsFactory)
+	o.ProgressiveRolloutFlags.Set(cmd, flagsFactory)
+	cmd.Flags().BoolVar(&o.ConvertToManual, "convert-to-manual", false, "Convert associated services to not manage their routes (keeps current traffic)")
+	return cmd
+}
+
+func (o *ProgressiveRolloutOptions) Run() error {
+	err := ValidateProgressiveRolloutSteps(o.ProgressiveRolloutFlags.Steps)
+	if err != nil {
+		return err
+	}
+
+	servingClient, err := o.depsFactory.ServingClient()
+	if err != nil {
+		return err
+	}
+
+	coreClient, err := o.depsFactory.CoreClient()
+	if err != nil {
+		return err
+	}
+
+	route, err := servingClient.ServingV1alpha1().Routes(o.RouteFlags.NamespaceFlags.Name).Get(o.RouteFlags.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Getting route: %s", err)
+	}
+
+	tags := ctlservice.NewTags(servingClient)
+
+	fromRevision, err := o.revision(o.ProgressiveRolloutFlags.From, tags, servingClient)
+	if err != nil {
+		return err
+	}
+
+	toRevision, err := o.revision(o.ProgressiveRolloutFlags.To, tags, servingClient)
+	if err != nil {
+		return err
+	}
+
+	if fromRevision.Name == toRevision.Name {
+		return fmt.Errorf("Expected revisions to be different but both were '%s'", toRevision.Name)
+	}
+
+	rolloutTargets := []v1alpha1.TrafficTarget{{RevisionName: fromRevision.Name}, {RevisionName: toRevision.Name}}
+
+	convertedNames, err := NewRouteServices(servingClient, o.RouteFlags.NamespaceFlags.Name).EnsureUnmanaged(
+		o.RouteFlags.Name, rolloutTargets, o.ConvertToManual)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range convertedNames {
+		o.ui.PrintLinef("Converted service '%s' to not manage its route", name)
+	}
+
+	state, err := NewProgressiveRolloutStateFromRoute(*route)
+	if err != nil {
+		return err
+	}
+
+	if state != nil {
+		if state.FromRevisionName != fromRevision.Name || state.ToRevisionName != toRevision.Name {
+			return fmt.Errorf("Expected route '%s' to not have progressive rollout from revision '%s' to revision '%s' "+
+				"in progress (remove '%s' annotation from route to discard it)", route.Name,
+				state.FromRevisionName, state.ToRevisionName, ProgressiveRolloutAnnotationKey)
+		}
+
+		err = state.CheckResume(o.ProgressiveRolloutFlags.Steps, o.ProgressiveRolloutFlags.Interval)
+		if err != nil {
+			return fmt.Errorf("%s (remove '%s' annotation from route '%s' to discard it)",
+				err, ProgressiveRolloutAnnotationKey, route.Name)
+		}
+
+		o.ui.PrintLinef("Resuming progressive rollout at step %d of %d", state.Step+1, len(state.Steps))
+	} else {
+		state = &ProgressiveRolloutState{
+			FromRevisionName: fromRevision.Name,
+			ToRevisionName:   toRevision.Name,
+			Steps:            o.ProgressiveRolloutFlags.Steps,
+			Interval:         metav1.Duration{Duration: o.ProgressiveRolloutFlags.Interval},
+			OriginalTraffic:  route.Spec.Traffic,
+		}
+	}
+
+	for {
+		o.ui.PrintLinef("Sending %d%% of traffic to revision '%s'", state.Steps[state.Step], state.ToRevisionName)
+
+		err = o.updateRoute(servingClient, state.Apply)
+		if err != nil {
+			return err
+		}
+
+		o.ui.PrintLinef("Waiting %s before checking revision '%s'", o.ProgressiveRolloutFlags.Interval, state.ToRevisionName)
+
+		time.Sleep(o.ProgressiveRolloutFlags.Interval)
+
+		checkErr := o.check(*state, servingClient, coreClient)
+		if checkErr != nil {
+			o.ui.PrintLinef("Restoring original traffic")
+
+			err = o.updateRoute(servingClient, func(route *v1alpha1.Route) error {
+				state.Revert(route)
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("Aborting progressive rollout: %s (restoring original traffic: %s)", checkErr, err)
+			}
+
+			return fmt.Errorf("Aborted progressive rollout (original traffic restored): %s", checkErr)
+		}
+
+		if state.IsLastStep() {
+			break
+		}
+
+		state.Step++
+	}
+
+	err = o.updateRoute(servingClient, func(route *v1alpha1.Route) error {
+		state.Finish(route)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	o.ui.PrintLinef("Progressive rollout to revision '%s' succeeded", state.ToRevisionName)
+
+	return nil
+}
+
+func (o *ProgressiveRolloutOptions) revision(name string, tags ctlservice.Tags, servingClient servingclientset.Interface) (*v1alpha1.Revision, error) {
+	revFlags := cmdflags.RevisionFlags{Name: name, NamespaceFlags: o.RouteFlags.NamespaceFlags}
+	return cmdrev.NewReference(revFlags, tags, servingClient).Revision()
+}
+
+func (o *ProgressiveRolloutOptions) check(state ProgressiveRolloutState, servingClient servingclientset.Interface, coreClient kubernetes.Interface) error {
+	revision, err := servingClient.ServingV1alpha1().Revisions(o.RouteFlags.NamespaceFlags.Name).Get(state.ToRevisionName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Getting revision: %s", err)
+	}
+
+	if !revision.Status.IsReady() {
+		var conds duckv1alpha1.Conditions
+
+		for _, cond := range revision.Status.Conditions {
+			if cond.Status != corev1.ConditionTrue {
+				conds = append(conds, cond)
+			}
+		}
+
+		cmdcore.NewConditionsTable(conds).Print(o.ui)
+
+		return fmt.Errorf("Expected revision '%s' to be ready", revision.Name)
+	}
+
+	// Fetch route again since its domain may have been assigned after rollout started
+	route, err := servingClient.ServingV1alpha1().Routes(o.RouteFlags.NamespaceFlags.Name).Get(o.RouteFlags.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Getting route: %s", err)
+	}
+
+	return o.probe(RouteAddress{route, coreClient}, state.ProbeTargetName())
+}
+
+// probe sends several requests through the ingress to the named traffic target
+// so that all of them are served by the new revision regardless of traffic split
+func (o *ProgressiveRolloutOptions) probe(routeAddr RouteAddress, targetName string) error {
+	domain, err := routeAddr.TargetDomain(targetName)
+	if err != nil {
+		return err
+	}
+
+	url, err := routeAddr.URL(o.ProgressiveRolloutFlags.ProbePort, false)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: progressiveRolloutProbeTimeout}
+
+	for i := 0; i < progressiveRolloutProbeRequests; i++ {
+		req, err := http.NewRequest("GET", url+o.ProgressiveRolloutFlags.ProbePath, nil)
+		if err != nil {
+			return fmt.Errorf("Building HTTP probe request: %s", err)
+		}
+
+		req.Host = domain
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("Expected HTTP probe to succeed: %s", err)
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("Expected HTTP probe to succeed but received status '%d'", resp.StatusCode)
+		}
+	}
+
+	return nil
+}
+
+func (o *ProgressiveRolloutOptions) updateRoute(servingClient servingclientset.Interface, updateFunc func(*v1alpha1.Route) error) error {
+	_, err := updateRoute(servingClient, o.RouteFlags.NamespaceFlags.Name, o.RouteFlags.Name, updateFunc)
+	return err
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"time"
+
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	"github.com/spf13/cobra"
+)
+
+type ProgressiveRolloutFlags struct {
+	From     string
+	To       string
+	Steps    []int
+	Interval time.Duration
+
+	ProbePath string
+	ProbePort int32
+}
+
+func (s *ProgressiveRolloutFlags) Set(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory) {
+	cmd.Flags().StringVar(&s.From, "from", "", "Set revision that currently receives traffic (format: revision, example: app-00001, app:previous)")
+	cmd.MarkFlagRequired("from")
+
+	cmd.Flags().StringVar(&s.To, "to", "", "Set revision that should receive traffic (format: revision, example: app-00002, app:latest)")
+	cmd.MarkFlagRequired("to")
+
+	cmd.Flags().IntSliceVar(&s.Steps, "steps", []int{5, 25, 50, 100}, "Set traffic percentages sent to new revision at each step")
+	cmd.Flags().DurationVar(&s.Interval, "interval", time.Minute, "Set time to wait at each step before checking revision health")
+
+	cmd.Flags().StringVar(&s.ProbePath, "probe-path", "/", "Set HTTP path requested through ingress at each step")
+	cmd.Flags().Int32Var(&s.ProbePort, "probe-port", 80, "Set ingress port used for HTTP probe")
+}
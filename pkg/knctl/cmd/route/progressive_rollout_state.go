@@ -0,0 +1,196 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ProgressiveRolloutAnnotationKey = "cli.knative.dev/progressiveRollout"
+)
+
+// ProgressiveRolloutState is recorded on the route while progressive rollout
+// is in progress so that an interrupted rollout can be resumed or reverted
+type ProgressiveRolloutState struct {
+	FromRevisionName string `json:"fromRevisionName"`
+	ToRevisionName   string `json:"toRevisionName"`
+
+	Steps    []int           `json:"steps"`
+	Interval metav1.Duration `json:"interval"`
+	// Index of the step that was applied last (it may not have been checked yet)
+	Step int `json:"step"`
+
+	OriginalTraffic []v1alpha1.TrafficTarget `json:"originalTraffic"`
+}
+
+func NewProgressiveRolloutStateFromRoute(route v1alpha1.Route) (*ProgressiveRolloutState, error) {
+	val, found := route.Annotations[ProgressiveRolloutAnnotationKey]
+	if !found {
+		return nil, nil
+	}
+
+	var state ProgressiveRolloutState
+
+	err := json.Unmarshal([]byte(val), &state)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling progressive rollout state of route '%s': %s", route.Name, err)
+	}
+
+	if state.Step < 0 || state.Step >= len(state.Steps) {
+		return nil, fmt.Errorf("Expected progressive rollout state of route '%s' to have valid step", route.Name)
+	}
+
+	return &state, nil
+}
+
+func ValidateProgressiveRolloutSteps(steps []int) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("Expected at least one rollout step")
+	}
+
+	for i, percent := range steps {
+		if percent <= 0 || percent > 100 {
+			return fmt.Errorf("Expected rollout step '%d' to be between 1%% and 100%%", percent)
+		}
+		if i > 0 && percent <= steps[i-1] {
+			return fmt.Errorf("Expected rollout steps to be in increasing order")
+		}
+	}
+
+	return nil
+}
+
+// CheckResume returns an error if rollout in progress was started with different settings
+func (s ProgressiveRolloutState) CheckResume(steps []int, interval time.Duration) error {
+	if !reflect.DeepEqual(s.Steps, steps) {
+		return fmt.Errorf("Expected rollout steps '%s' to match steps '%s' of progressive rollout in progress",
+			s.formatSteps(steps), s.formatSteps(s.Steps))
+	}
+
+	if s.Interval.Duration != interval {
+		return fmt.Errorf("Expected rollout interval '%s' to match interval '%s' of progressive rollout in progress",
+			interval, s.Interval.Duration)
+	}
+
+	return nil
+}
+
+// ProbeTargetName returns name of the traffic target that only sends traffic
+// to the new revision so that it could be probed directly ({name}.{route domain})
+func (s ProgressiveRolloutState) ProbeTargetName() string {
+	return s.ToRevisionName
+}
+
+// Traffic returns route traffic for given step. Traffic that original route sent
+// to either revision is split between them and other traffic targets are kept as is.
+// If original route did not send traffic to either revision directly (e.g. it sent it
+// via configuration target), all of its traffic is split between them.
+func (s ProgressiveRolloutState) Traffic(step int) []v1alpha1.TrafficTarget {
+	var otherTargets []v1alpha1.TrafficTarget
+	var rolloutPercent int
+
+	for _, target := range s.OriginalTraffic {
+		if s.isRolloutTarget(target) {
+			rolloutPercent += target.Percent
+		} else {
+			otherTargets = append(otherTargets, target)
+		}
+	}
+
+	if rolloutPercent == 0 {
+		rolloutPercent = 100
+
+		var zeroTargets []v1alpha1.TrafficTarget
+
+		for _, target := range otherTargets {
+			if target.Percent == 0 {
+				zeroTargets = append(zeroTargets, target)
+			}
+		}
+
+		otherTargets = zeroTargets
+	}
+
+	toPercent := rolloutPercent * s.Steps[step] / 100
+
+	targets := []v1alpha1.TrafficTarget{{RevisionName: s.ToRevisionName, Percent: toPercent}}
+
+	if toPercent < rolloutPercent {
+		targets = append(targets, v1alpha1.TrafficTarget{RevisionName: s.FromRevisionName, Percent: rolloutPercent - toPercent})
+	}
+
+	return append(targets, otherTargets...)
+}
+
+// isRolloutTarget returns true for targets that send traffic to either revision
+// (named targets are kept since they are used to address revisions directly)
+func (s ProgressiveRolloutState) isRolloutTarget(target v1alpha1.TrafficTarget) bool {
+	return len(target.Name) == 0 && target.Percent > 0 &&
+		(target.RevisionName == s.FromRevisionName || target.RevisionName == s.ToRevisionName)
+}
+
+func (s ProgressiveRolloutState) IsLastStep() bool {
+	return s.Step == len(s.Steps)-1
+}
+
+func (s ProgressiveRolloutState) Apply(route *v1alpha1.Route) error {
+	bs, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("Marshaling progressive rollout state: %s", err)
+	}
+
+	if route.Annotations == nil {
+		route.Annotations = map[string]string{}
+	}
+
+	route.Annotations[ProgressiveRolloutAnnotationKey] = string(bs)
+	route.Spec.Traffic = append(s.Traffic(s.Step), v1alpha1.TrafficTarget{
+		Name:         s.ProbeTargetName(),
+		RevisionName: s.ToRevisionName,
+		Percent:      0,
+	})
+
+	return nil
+}
+
+func (s ProgressiveRolloutState) Revert(route *v1alpha1.Route) {
+	delete(route.Annotations, ProgressiveRolloutAnnotationKey)
+	route.Spec.Traffic = s.OriginalTraffic
+}
+
+func (s ProgressiveRolloutState) Finish(route *v1alpha1.Route) {
+	delete(route.Annotations, ProgressiveRolloutAnnotationKey)
+	route.Spec.Traffic = s.Traffic(s.Step) // without probe target
+}
+
+func (ProgressiveRolloutState) formatSteps(steps []int) string {
+	var result string
+	for i, step := range steps {
+		if i > 0 {
+			result += ","
+		}
+		result += fmt.Sprintf("%d", step)
+	}
+	return result
+}
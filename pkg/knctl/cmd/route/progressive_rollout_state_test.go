@@ -0,0 +1,234 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/route"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProgressiveRolloutStateRoundTrip(t *testing.T) {
+	origTraffic := []v1alpha1.TrafficTarget{{ConfigurationName: "srv1", Percent: 100}}
+
+	state := ProgressiveRolloutState{
+		FromRevisionName: "srv1-00001",
+		ToRevisionName:   "srv1-00002",
+		Steps:            []int{5, 50, 100},
+		Interval:         metav1.Duration{Duration: 2 * time.Minute},
+		Step:             1,
+		OriginalTraffic:  origTraffic,
+	}
+
+	route := v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "rt1"},
+		Spec:       v1alpha1.RouteSpec{Traffic: origTraffic},
+	}
+
+	err := state.Apply(&route)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	expectedTraffic := []v1alpha1.TrafficTarget{
+		{RevisionName: "srv1-00002", Percent: 50},
+		{RevisionName: "srv1-00001", Percent: 50},
+		{Name: "srv1-00002", RevisionName: "srv1-00002", Percent: 0},
+	}
+
+	if !reflect.DeepEqual(route.Spec.Traffic, expectedTraffic) {
+		t.Fatalf("Expected traffic '%#v' to equal '%#v'", route.Spec.Traffic, expectedTraffic)
+	}
+
+	loadedState, err := NewProgressiveRolloutStateFromRoute(route)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if !reflect.DeepEqual(*loadedState, state) {
+		t.Fatalf("Expected state '%#v' to equal '%#v'", *loadedState, state)
+	}
+
+	loadedState.Revert(&route)
+
+	if !reflect.DeepEqual(route.Spec.Traffic, origTraffic) {
+		t.Fatalf("Expected traffic '%#v' to be restored to '%#v'", route.Spec.Traffic, origTraffic)
+	}
+
+	loadedState, err = NewProgressiveRolloutStateFromRoute(route)
+	if err != nil || loadedState != nil {
+		t.Fatalf("Expected state to be removed: %#v, %s", loadedState, err)
+	}
+}
+
+func TestProgressiveRolloutStateTrafficForLastStep(t *testing.T) {
+	state := ProgressiveRolloutState{
+		FromRevisionName: "srv1-00001",
+		ToRevisionName:   "srv1-00002",
+		Steps:            []int{5, 100},
+		Step:             1,
+	}
+
+	if !state.IsLastStep() {
+		t.Fatalf("Expected step to be last")
+	}
+
+	expectedTraffic := []v1alpha1.TrafficTarget{{RevisionName: "srv1-00002", Percent: 100}}
+
+	if !reflect.DeepEqual(state.Traffic(1), expectedTraffic) {
+		t.Fatalf("Expected traffic '%#v' to equal '%#v'", state.Traffic(1), expectedTraffic)
+	}
+}
+
+func TestProgressiveRolloutStateFinishRemovesProbeTarget(t *testing.T) {
+	state := ProgressiveRolloutState{
+		FromRevisionName: "srv1-00001",
+		ToRevisionName:   "srv1-00002",
+		Steps:            []int{5, 100},
+		Step:             1,
+	}
+
+	var route v1alpha1.Route
+
+	err := state.Apply(&route)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	state.Finish(&route)
+
+	expectedTraffic := []v1alpha1.TrafficTarget{{RevisionName: "srv1-00002", Percent: 100}}
+
+	if !reflect.DeepEqual(route.Spec.Traffic, expectedTraffic) {
+		t.Fatalf("Expected traffic '%#v' to equal '%#v'", route.Spec.Traffic, expectedTraffic)
+	}
+
+	if _, found := route.Annotations[ProgressiveRolloutAnnotationKey]; found {
+		t.Fatalf("Expected state annotation to be removed")
+	}
+}
+
+func TestProgressiveRolloutStateKeepsOtherTargets(t *testing.T) {
+	state := ProgressiveRolloutState{
+		FromRevisionName: "srv1-00001",
+		ToRevisionName:   "srv1-00002",
+		Steps:            []int{5, 50, 100},
+		Step:             1,
+		OriginalTraffic: []v1alpha1.TrafficTarget{
+			{RevisionName: "srv1-00001", Percent: 80},
+			{RevisionName: "srv2-00001", Percent: 20},
+			{Name: "stable", RevisionName: "srv1-00001", Percent: 0},
+		},
+	}
+
+	var route v1alpha1.Route
+
+	err := state.Apply(&route)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	expectedTraffic := []v1alpha1.TrafficTarget{
+		{RevisionName: "srv1-00002", Percent: 40},
+		{RevisionName: "srv1-00001", Percent: 40},
+		{RevisionName: "srv2-00001", Percent: 20},
+		{Name: "stable", RevisionName: "srv1-00001", Percent: 0},
+		{Name: "srv1-00002", RevisionName: "srv1-00002", Percent: 0},
+	}
+
+	if !reflect.DeepEqual(route.Spec.Traffic, expectedTraffic) {
+		t.Fatalf("Expected traffic '%#v' to equal '%#v'", route.Spec.Traffic, expectedTraffic)
+	}
+
+	state.Step = 2
+	state.Finish(&route)
+
+	expectedTraffic = []v1alpha1.TrafficTarget{
+		{RevisionName: "srv1-00002", Percent: 80},
+		{RevisionName: "srv2-00001", Percent: 20},
+		{Name: "stable", RevisionName: "srv1-00001", Percent: 0},
+	}
+
+	if !reflect.DeepEqual(route.Spec.Traffic, expectedTraffic) {
+		t.Fatalf("Expected traffic '%#v' to equal '%#v'", route.Spec.Traffic, expectedTraffic)
+	}
+}
+
+func TestProgressiveRolloutStateCheckResume(t *testing.T) {
+	state := ProgressiveRolloutState{
+		Steps:    []int{5, 50, 100},
+		Interval: metav1.Duration{Duration: 2 * time.Minute},
+	}
+
+	examples := []struct {
+		Steps       []int
+		Interval    time.Duration
+		ExpectedErr string
+	}{
+		{Steps: []int{5, 50, 100}, Interval: 2 * time.Minute},
+		{
+			Steps:       []int{5, 25, 50, 100},
+			Interval:    2 * time.Minute,
+			ExpectedErr: "Expected rollout steps '5,25,50,100' to match steps '5,50,100' of progressive rollout in progress",
+		},
+		{
+			Steps:       []int{5, 50, 100},
+			Interval:    time.Minute,
+			ExpectedErr: "Expected rollout interval '1m0s' to match interval '2m0s' of progressive rollout in progress",
+		},
+	}
+
+	for _, ex := range examples {
+		err := state.CheckResume(ex.Steps, ex.Interval)
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected error '%s' for steps '%v' and interval '%s' but was '%v'", ex.ExpectedErr, ex.Steps, ex.Interval, err)
+			}
+		} else if err != nil {
+			t.Fatalf("Expected no error for steps '%v' and interval '%s': %s", ex.Steps, ex.Interval, err)
+		}
+	}
+}
+
+func TestValidateProgressiveRolloutSteps(t *testing.T) {
+	examples := []struct {
+		Steps       []int
+		ExpectedErr string
+	}{
+		{Steps: []int{5, 25, 50, 100}},
+		{Steps: []int{10}},
+		{Steps: nil, ExpectedErr: "Expected at least one rollout step"},
+		{Steps: []int{0, 100}, ExpectedErr: "Expected rollout step '0' to be between 1% and 100%"},
+		{Steps: []int{50, 101}, ExpectedErr: "Expected rollout step '101' to be between 1% and 100%"},
+		{Steps: []int{50, 25}, ExpectedErr: "Expected rollout steps to be in increasing order"},
+	}
+
+	for _, ex := range examples {
+		err := ValidateProgressiveRolloutSteps(ex.Steps)
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected error '%s' for steps '%v' but was '%v'", ex.ExpectedErr, ex.Steps, err)
+			}
+		} else if err != nil {
+			t.Fatalf("Expected no error for steps '%v': %s", ex.Steps, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/route"
+)
+
+func TestNewProgressiveRolloutCmd_Ok(t *testing.T) {
+	realCmd := NewProgressiveRolloutOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewProgressiveRolloutCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.ExpectBasicConfig()
+	cmd.Execute([]string{
+		"-n", "test-namespace",
+		"--route", "test-route",
+		"--from", "srv1:previous",
+		"--to", "srv1:latest",
+		"--steps", "10,50,100",
+		"--interval", "2m",
+		"--probe-path", "/health",
+		"--probe-port", "443",
+		"--convert-to-manual",
+	})
+	cmd.ExpectReachesExecution()
+
+	DeepEqual(t, realCmd.RouteFlags,
+		RouteFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-route"})
+
+	DeepEqual(t, realCmd.ProgressiveRolloutFlags, ProgressiveRolloutFlags{
+		From:      "srv1:previous",
+		To:        "srv1:latest",
+		Steps:     []int{10, 50, 100},
+		Interval:  2 * time.Minute,
+		ProbePath: "/health",
+		ProbePort: 443,
+	})
+
+	DeepEqual(t, realCmd.ConvertToManual, true)
+}
+
+func TestNewProgressiveRolloutCmd_OkMinimum(t *testing.T) {
+	realCmd := NewProgressiveRolloutOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewProgressiveRolloutCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.ExpectBasicConfig()
+	cmd.Execute([]string{
+		"-n", "test-namespace",
+		"--route", "test-route",
+		"--from", "srv1:previous",
+		"--to", "srv1:latest",
+	})
+	cmd.ExpectReachesExecution()
+
+	DeepEqual(t, realCmd.ProgressiveRolloutFlags, ProgressiveRolloutFlags{
+		From:      "srv1:previous",
+		To:        "srv1:latest",
+		Steps:     []int{5, 25, 50, 100},
+		Interval:  time.Minute,
+		ProbePath: "/",
+		ProbePort: 80,
+	})
+}
+
+func TestNewProgressiveRolloutCmd_RequiredFlags(t *testing.T) {
+	realCmd := NewProgressiveRolloutOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewProgressiveRolloutCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.Execute([]string{})
+	cmd.ExpectRequiredFlags([]string{"from", "route", "to"})
+}
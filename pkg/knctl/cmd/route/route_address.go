@@ -30,6 +30,10 @@ type RouteAddress struct {
 	coreClient kubernetes.Interface
 }
 
+func NewRouteAddress(route *v1alpha1.Route, coreClient kubernetes.Interface) RouteAddress {
+	return RouteAddress{route, coreClient}
+}
+
 func (o RouteAddress) Domain() (string, error) {
 	if len(o.route.Status.Domain) == 0 {
 		return "", fmt.Errorf("Expected route '%s' to have non-empty domain", o.route.Name)
@@ -38,6 +42,22 @@ func (o RouteAddress) Domain() (string, error) {
 	return o.route.Status.Domain, nil
 }
 
+// TargetDomain returns domain of the named traffic target
+func (o RouteAddress) TargetDomain(name string) (string, error) {
+	domain, err := o.Domain()
+	if err != nil {
+		return "", err
+	}
+
+	for _, target := range o.route.Status.Traffic {
+		if target.Name == name {
+			return name + "." + domain, nil
+		}
+	}
+
+	return "", fmt.Errorf("Expected route '%s' to have traffic target named '%s'", o.route.Name, name)
+}
+
 func (o RouteAddress) URL(port int32, useDomain bool) (string, error) {
 	domain, err := o.Domain()
 	if err != nil {
@@ -0,0 +1,175 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cppforlife/knctl/pkg/knctl/util"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RouteServices finds services affected by route traffic changes: service that
+// created the route and services that own configurations of traffic targets.
+// Such services must not manage their routes, otherwise they would revert traffic changes.
+type RouteServices struct {
+	servingClient servingclientset.Interface
+	namespace     string
+}
+
+func NewRouteServices(servingClient servingclientset.Interface, namespace string) RouteServices {
+	return RouteServices{servingClient, namespace}
+}
+
+// EnsureUnmanaged returns an error if any of affected services manages its route,
+// unless convertToManual is true, in which case such services are switched to manual mode.
+// Returns names of converted services.
+func (s RouteServices) EnsureUnmanaged(routeName string, targets []v1alpha1.TrafficTarget, convertToManual bool) ([]string, error) {
+	managedNames, err := s.ManagedNames(routeName, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(managedNames) == 0 {
+		return nil, nil
+	}
+
+	if !convertToManual {
+		return nil, NewManagedRouteServicesErr(managedNames)
+	}
+
+	for _, name := range managedNames {
+		err := s.convertToManual(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return managedNames, nil
+}
+
+// ManagedNames returns names of affected services that manage their routes
+func (s RouteServices) ManagedNames(routeName string, targets []v1alpha1.TrafficTarget) ([]string, error) {
+	serviceNames, err := s.Names(routeName, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	var managedNames []string
+
+	for _, name := range serviceNames {
+		service, err := s.servingClient.ServingV1alpha1().Services(s.namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("Getting associated service: %s", err)
+		}
+
+		if service.Spec.Manual == nil {
+			managedNames = append(managedNames, name)
+		}
+	}
+
+	return managedNames, nil
+}
+
+func NewManagedRouteServicesErr(names []string) error {
+	hintMsg := "(use `--managed-route=false` flag when running `deploy` command, or `--convert-to-manual` flag)"
+	return fmt.Errorf("Expected associated services '%s' to not manage routes %s", strings.Join(names, "', '"), hintMsg)
+}
+
+// Names returns names of services affected by route traffic changes
+func (s RouteServices) Names(routeName string, targets []v1alpha1.TrafficTarget) ([]string, error) {
+	var names []string
+	seenNames := map[string]struct{}{}
+
+	addName := func(name string) {
+		if _, found := seenNames[name]; !found && len(name) > 0 {
+			seenNames[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+
+	route, err := s.servingClient.ServingV1alpha1().Routes(s.namespace).Get(routeName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("Getting route: %s", err)
+		}
+	} else {
+		addName(route.Labels[serving.ServiceLabelKey])
+	}
+
+	confNames := map[string]struct{}{}
+
+	for _, target := range targets {
+		confName := target.ConfigurationName
+
+		if len(target.RevisionName) > 0 {
+			revision, err := s.servingClient.ServingV1alpha1().Revisions(s.namespace).Get(target.RevisionName, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("Getting revision: %s", err)
+			}
+
+			confName = revision.Labels[serving.ConfigurationLabelKey]
+		}
+
+		if _, found := confNames[confName]; found || len(confName) == 0 {
+			continue
+		}
+
+		confNames[confName] = struct{}{}
+
+		conf, err := s.servingClient.ServingV1alpha1().Configurations(s.namespace).Get(confName, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("Getting configuration: %s", err)
+		}
+
+		addName(conf.Labels[serving.ServiceLabelKey])
+	}
+
+	return names, nil
+}
+
+// convertToManual stops service from managing its route and configuration.
+// Knative keeps existing route and configuration as is, hence traffic is not affected.
+func (s RouteServices) convertToManual(name string) error {
+	return util.Retry(time.Second, 10*time.Second, func() (bool, error) {
+		service, err := s.servingClient.ServingV1alpha1().Services(s.namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("Getting service: %s", err)
+		}
+
+		service.Spec = v1alpha1.ServiceSpec{Manual: &v1alpha1.ManualType{}}
+
+		_, err = s.servingClient.ServingV1alpha1().Services(s.namespace).Update(service)
+		if err != nil {
+			return false, fmt.Errorf("Updating service: %s", err)
+		}
+
+		return true, nil
+	})
+}
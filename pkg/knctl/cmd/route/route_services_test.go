@@ -0,0 +1,133 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/route"
+	"github.com/cppforlife/knctl/pkg/knctl/fakes"
+	"github.com/cppforlife/knctl/pkg/knctl/util"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMain(m *testing.M) {
+	// Fake serving client reflects changes immediately
+	util.RetryInterval = time.Millisecond
+	os.Exit(m.Run())
+}
+
+func newRouteServicesServingClient() *fakes.ServingClient {
+	servingClient := fakes.NewServingClient()
+
+	for _, name := range []string{"svc1", "svc2", "svc3"} {
+		servingClient.AddService(v1alpha1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			Spec:       v1alpha1.ServiceSpec{RunLatest: &v1alpha1.RunLatestType{}},
+		})
+
+		servingClient.AddConfiguration(v1alpha1.Configuration{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1", Labels: map[string]string{serving.ServiceLabelKey: name}},
+		})
+
+		servingClient.AddRevision(v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-00001", Namespace: "ns1", Labels: map[string]string{serving.ConfigurationLabelKey: name}},
+		})
+
+		servingClient.AddRoute(v1alpha1.Route{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1", Labels: map[string]string{serving.ServiceLabelKey: name}},
+		})
+	}
+
+	servingClient.AddService(v1alpha1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc2", Namespace: "ns1"},
+		Spec:       v1alpha1.ServiceSpec{Manual: &v1alpha1.ManualType{}},
+	})
+
+	servingClient.AddRoute(v1alpha1.Route{ObjectMeta: metav1.ObjectMeta{Name: "standalone", Namespace: "ns1"}})
+
+	return servingClient
+}
+
+func TestRouteServicesNames(t *testing.T) {
+	servingClient := newRouteServicesServingClient()
+
+	targets := []v1alpha1.TrafficTarget{
+		{RevisionName: "svc1-00001", Percent: 50},
+		{ConfigurationName: "svc2", Percent: 25},
+		{RevisionName: "svc2-00001", Percent: 25},
+	}
+
+	names, err := NewRouteServices(servingClient, "ns1").Names("svc3", targets)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	expectedNames := []string{"svc3", "svc1", "svc2"}
+
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("Expected names '%#v' to equal '%#v'", names, expectedNames)
+	}
+}
+
+func TestRouteServicesEnsureUnmanaged(t *testing.T) {
+	servingClient := newRouteServicesServingClient()
+
+	targets := []v1alpha1.TrafficTarget{
+		{RevisionName: "svc1-00001", Percent: 50},
+		{RevisionName: "svc2-00001", Percent: 25},
+		{RevisionName: "svc3-00001", Percent: 25},
+	}
+
+	_, err := NewRouteServices(servingClient, "ns1").EnsureUnmanaged("standalone", targets, false)
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	expectedErr := "Expected associated services 'svc1', 'svc3' to not manage routes " +
+		"(use `--managed-route=false` flag when running `deploy` command, or `--convert-to-manual` flag)"
+
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+
+	convertedNames, err := NewRouteServices(servingClient, "ns1").EnsureUnmanaged("standalone", targets, true)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if !reflect.DeepEqual(convertedNames, []string{"svc1", "svc3"}) {
+		t.Fatalf("Expected converted services to be 'svc1', 'svc3' but was '%#v'", convertedNames)
+	}
+
+	for _, name := range []string{"svc1", "svc2", "svc3"} {
+		spec := servingClient.Service("ns1", name).Spec
+		if spec.Manual == nil || spec.RunLatest != nil {
+			t.Fatalf("Expected service '%s' to be in manual mode but was '%#v'", name, spec)
+		}
+	}
+
+	convertedNames, err = NewRouteServices(servingClient, "ns1").EnsureUnmanaged("standalone", targets, false)
+	if err != nil || len(convertedNames) > 0 {
+		t.Fatalf("Expected no services to be converted: %#v, %v", convertedNames, err)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	"github.com/cppforlife/knctl/pkg/knctl/util"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updateRoute applies updateFunc to the latest version of the route (retrying on conflicts).
+// Error returned from updateFunc is not retried.
+func updateRoute(servingClient servingclientset.Interface, namespace, name string,
+	updateFunc func(*v1alpha1.Route) error) (*v1alpha1.Route, error) {
+
+	var updatedRoute *v1alpha1.Route
+	var updateErr error
+
+	retryErr := util.Retry(time.Second, 10*time.Second, func() (bool, error) {
+		route, err := servingClient.ServingV1alpha1().Routes(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("Getting route: %s", err)
+		}
+
+		updateErr = updateFunc(route)
+		if updateErr != nil {
+			return true, nil
+		}
+
+		updatedRoute, err = servingClient.ServingV1alpha1().Routes(namespace).Update(route)
+		if err != nil {
+			return false, fmt.Errorf("Updating route: %s", err)
+		}
+
+		return true, nil
+	})
+	if retryErr != nil {
+		return nil, retryErr
+	}
+
+	return updatedRoute, updateErr
+}
+
+// waitForRouteTraffic waits for route to become ready with its spec traffic
+func waitForRouteTraffic(servingClient servingclientset.Interface, route *v1alpha1.Route, timeout time.Duration, ui ui.UI) error {
+	ui.PrintLinef("Waiting for route '%s' to become ready", route.Name)
+
+	var lastRoute *v1alpha1.Route
+
+	err := util.Retry(time.Second, timeout, func() (bool, error) {
+		currRoute, err := servingClient.ServingV1alpha1().Routes(route.Namespace).Get(route.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("Getting route: %s", err)
+		}
+
+		lastRoute = currRoute
+
+		if currRoute.Status.ObservedGeneration < route.Spec.Generation || !currRoute.Status.IsReady() ||
+			!TrafficTargetsApplied(currRoute.Status.Traffic, route.Spec.Traffic) {
+			return false, fmt.Errorf("Expected route '%s' to become ready with new traffic", route.Name)
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		if lastRoute != nil {
+			cmdcore.NewConditionsTable(lastRoute.Status.Conditions).Print(ui)
+		}
+		return err
+	}
+
+	ui.PrintLinef("Route '%s' is ready", route.Name)
+
+	return nil
+}
@@ -105,11 +105,13 @@ func (o *ShowOptions) printTargets(route *v1alpha1.Route) {
 			uitable.NewHeader("Percent"),
 			uitable.NewHeader("Revision"),
 			uitable.NewHeader("Service"),
-			uitable.NewHeader("Domain"),
+			uitable.NewHeader("Name"),
+			uitable.NewHeader("URL"),
 		},
 	}
 
 	for _, tr := range route.Status.Traffic {
+		// Named targets are reachable at their own domain regardless of their percentage
 		domain := route.Status.Domain
 		if len(tr.Name) > 0 {
 			domain = tr.Name + "." + route.Status.Domain
@@ -119,7 +121,8 @@ func (o *ShowOptions) printTargets(route *v1alpha1.Route) {
 			uitable.NewValueSuffix(uitable.NewValueInt(tr.Percent), "%"),
 			uitable.NewValueString(tr.RevisionName),
 			uitable.NewValueString(tr.ConfigurationName),
-			uitable.NewValueString(domain),
+			uitable.NewValueString(tr.Name),
+			uitable.NewValueString("http://" + domain),
 		})
 	}
 
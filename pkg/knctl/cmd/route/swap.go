@@ -0,0 +1,222 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	cmdrev "github.com/cppforlife/knctl/pkg/knctl/cmd/revision"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	SwapPreviousTrafficAnnotationKey = "cli.knative.dev/swapPreviousTraffic"
+)
+
+type SwapOptions struct {
+	ui          ui.UI
+	depsFactory cmdcore.DepsFactory
+
+	RouteFlags RouteFlags
+	SwapFlags  SwapFlags
+}
+
+func NewSwapOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *SwapOptions {
+	return &SwapOptions{ui: ui, depsFactory: depsFactory}
+}
+
+func NewSwapCmd(o *SwapOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "swap",
+		Short: "Swap route traffic",
+		Long: `Send all route traffic to green revision instead of blue revision in a single update.
+
+Route traffic before the swap is recorded on the route so that it can be restored with '--undo' flag.
+Command waits until route starts sending traffic according to the change.`,
+		Example: `
+  # Send all traffic of route 'rt1' in namespace 'ns1' to latest revision of service 'svc1'
+  knctl route swap --route rt1 --blue svc1:previous --green svc1:latest -n ns1
+
+  # Restore traffic of route 'rt1' in namespace 'ns1' to what it was before last swap
+  knctl route swap --route rt1 --undo -n ns1`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	o.RouteFlags.Set(cmd, flagsFactory)
+	o.SwapFlags.Set(cmd, flagsFactory)
+	return cmd
+}
+
+func (o *SwapOptions) Run() error {
+	if o.SwapFlags.Undo {
+		if len(o.SwapFlags.Blue) > 0 || len(o.SwapFlags.Green) > 0 {
+			return fmt.Errorf("Expected '--blue' and '--green' flags to not be used with '--undo' flag")
+		}
+	} else {
+		if len(o.SwapFlags.Blue) == 0 || len(o.SwapFlags.Green) == 0 {
+			return fmt.Errorf("Expected '--blue' and '--green' flags to be specified (unless '--undo' flag is used)")
+		}
+	}
+
+	servingClient, err := o.depsFactory.ServingClient()
+	if err != nil {
+		return err
+	}
+
+	var updatedRoute *v1alpha1.Route
+
+	if o.SwapFlags.Undo {
+		updatedRoute, err = o.undo(servingClient)
+	} else {
+		updatedRoute, err = o.swap(servingClient)
+	}
+	if err != nil {
+		return err
+	}
+
+	return waitForRouteTraffic(servingClient, updatedRoute, o.SwapFlags.WaitTimeout, o.ui)
+}
+
+func (o *SwapOptions) swap(servingClient servingclientset.Interface) (*v1alpha1.Route, error) {
+	tags := ctlservice.NewTags(servingClient)
+
+	blueRevision, err := o.revision(o.SwapFlags.Blue, tags, servingClient)
+	if err != nil {
+		return nil, err
+	}
+
+	greenRevision, err := o.revision(o.SwapFlags.Green, tags, servingClient)
+	if err != nil {
+		return nil, err
+	}
+
+	if blueRevision.Name == greenRevision.Name {
+		return nil, fmt.Errorf("Expected blue and green revisions to be different but both were '%s'", blueRevision.Name)
+	}
+
+	targets := []v1alpha1.TrafficTarget{{RevisionName: greenRevision.Name, Percent: 100}}
+	swapTargets := []v1alpha1.TrafficTarget{{RevisionName: blueRevision.Name}, targets[0]}
+
+	err = o.ensureUnmanagedServices(servingClient, swapTargets)
+	if err != nil {
+		return nil, err
+	}
+
+	o.ui.PrintLinef("Swapping traffic from revision '%s' to revision '%s'", blueRevision.Name, greenRevision.Name)
+
+	return updateRoute(servingClient, o.RouteFlags.NamespaceFlags.Name, o.RouteFlags.Name, func(route *v1alpha1.Route) error {
+		if !o.receivesTraffic(route, blueRevision.Name) {
+			return fmt.Errorf("Expected blue revision '%s' to receive traffic from route '%s'", blueRevision.Name, route.Name)
+		}
+
+		prevTrafficBs, err := json.Marshal(route.Spec.Traffic)
+		if err != nil {
+			return fmt.Errorf("Marshaling route traffic: %s", err)
+		}
+
+		if route.Annotations == nil {
+			route.Annotations = map[string]string{}
+		}
+
+		route.Annotations[SwapPreviousTrafficAnnotationKey] = string(prevTrafficBs)
+		route.Spec.Traffic = targets
+
+		return nil
+	})
+}
+
+func (o *SwapOptions) undo(servingClient servingclientset.Interface) (*v1alpha1.Route, error) {
+	route, err := servingClient.ServingV1alpha1().Routes(o.RouteFlags.NamespaceFlags.Name).Get(o.RouteFlags.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Getting route: %s", err)
+	}
+
+	prevTraffic, err := o.previousTraffic(route)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.ensureUnmanagedServices(servingClient, prevTraffic)
+	if err != nil {
+		return nil, err
+	}
+
+	o.ui.PrintLinef("Restoring traffic of route '%s' before last swap", route.Name)
+
+	return updateRoute(servingClient, o.RouteFlags.NamespaceFlags.Name, o.RouteFlags.Name, func(route *v1alpha1.Route) error {
+		prevTraffic, err := o.previousTraffic(route)
+		if err != nil {
+			return err
+		}
+
+		delete(route.Annotations, SwapPreviousTrafficAnnotationKey)
+		route.Spec.Traffic = prevTraffic
+
+		return nil
+	})
+}
+
+func (o *SwapOptions) previousTraffic(route *v1alpha1.Route) ([]v1alpha1.TrafficTarget, error) {
+	val, found := route.Annotations[SwapPreviousTrafficAnnotationKey]
+	if !found {
+		return nil, fmt.Errorf("Expected route '%s' to have recorded traffic before last swap", route.Name)
+	}
+
+	var prevTraffic []v1alpha1.TrafficTarget
+
+	err := json.Unmarshal([]byte(val), &prevTraffic)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling route traffic before last swap: %s", err)
+	}
+
+	return prevTraffic, nil
+}
+
+func (o *SwapOptions) receivesTraffic(route *v1alpha1.Route, revisionName string) bool {
+	for _, target := range route.Status.Traffic {
+		if target.RevisionName == revisionName && target.Percent > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *SwapOptions) ensureUnmanagedServices(servingClient servingclientset.Interface, targets []v1alpha1.TrafficTarget) error {
+	convertedNames, err := NewRouteServices(servingClient, o.RouteFlags.NamespaceFlags.Name).EnsureUnmanaged(
+		o.RouteFlags.Name, targets, o.SwapFlags.ConvertToManual)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range convertedNames {
+		o.ui.PrintLinef("Converted service '%s' to not manage its route", name)
+	}
+
+	return nil
+}
+
+func (o *SwapOptions) revision(name string, tags ctlservice.Tags, servingClient servingclientset.Interface) (*v1alpha1.Revision, error) {
+	revFlags := cmdflags.RevisionFlags{Name: name, NamespaceFlags: o.RouteFlags.NamespaceFlags}
+	return cmdrev.NewReference(revFlags, tags, servingClient).Revision()
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"time"
+
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	"github.com/spf13/cobra"
+)
+
+type SwapFlags struct {
+	Blue  string
+	Green string
+	Undo  bool
+
+	WaitTimeout     time.Duration
+	ConvertToManual bool
+}
+
+func (s *SwapFlags) Set(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory) {
+	cmd.Flags().StringVar(&s.Blue, "blue", "", "Set revision that currently receives traffic (format: revision, example: app-00001, app:previous)")
+	cmd.Flags().StringVar(&s.Green, "green", "", "Set revision that should receive all traffic (format: revision, example: app-00002, app:latest)")
+	cmd.Flags().BoolVar(&s.Undo, "undo", false, "Restore traffic that route had before last swap")
+
+	cmd.Flags().DurationVar(&s.WaitTimeout, "wait-timeout", 5*time.Minute, "Set timeout for waiting for route to become ready")
+	cmd.Flags().BoolVar(&s.ConvertToManual, "convert-to-manual", false, "Convert associated services to not manage their routes (keeps current traffic)")
+}
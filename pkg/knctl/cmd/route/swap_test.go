@@ -0,0 +1,164 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/route"
+	"github.com/cppforlife/knctl/pkg/knctl/fakes"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewSwapCmd_Ok(t *testing.T) {
+	realCmd := NewSwapOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewSwapCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.ExpectBasicConfig()
+	cmd.Execute([]string{
+		"-n", "test-namespace",
+		"--route", "test-route",
+		"--blue", "srv1:previous",
+		"--green", "srv1:latest",
+		"--wait-timeout", "1m",
+		"--convert-to-manual",
+	})
+	cmd.ExpectReachesExecution()
+
+	DeepEqual(t, realCmd.RouteFlags,
+		RouteFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-route"})
+
+	DeepEqual(t, realCmd.SwapFlags, SwapFlags{
+		Blue:            "srv1:previous",
+		Green:           "srv1:latest",
+		WaitTimeout:     time.Minute,
+		ConvertToManual: true,
+	})
+}
+
+func TestNewSwapCmd_OkUndo(t *testing.T) {
+	realCmd := NewSwapOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewSwapCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.ExpectBasicConfig()
+	cmd.Execute([]string{
+		"-n", "test-namespace",
+		"--route", "test-route",
+		"--undo",
+	})
+	cmd.ExpectReachesExecution()
+
+	DeepEqual(t, realCmd.SwapFlags, SwapFlags{Undo: true, WaitTimeout: 5 * time.Minute})
+}
+
+func TestNewSwapCmd_RequiredFlags(t *testing.T) {
+	realCmd := NewSwapOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewSwapCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.Execute([]string{})
+	cmd.ExpectRequiredFlags([]string{"route"})
+}
+
+func TestSwapAndUndo(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+	servingClient.ReconcileRoutes()
+
+	servingClient.AddRevision(v1alpha1.Revision{ObjectMeta: metav1.ObjectMeta{Name: "srv1-00001", Namespace: "ns1"}})
+	servingClient.AddRevision(v1alpha1.Revision{ObjectMeta: metav1.ObjectMeta{Name: "srv1-00002", Namespace: "ns1"}})
+
+	origTraffic := []v1alpha1.TrafficTarget{
+		{RevisionName: "srv1-00001", Percent: 90},
+		{RevisionName: "srv1-00002", Percent: 10, Name: "candidate"},
+	}
+
+	servingClient.AddRoute(v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "rt1", Namespace: "ns1"},
+		Spec:       v1alpha1.RouteSpec{Traffic: origTraffic},
+		Status:     v1alpha1.RouteStatus{Traffic: origTraffic},
+	})
+
+	newSwapOptions := func(flags SwapFlags) *SwapOptions {
+		o := NewSwapOptions(ui.NewNoopUI(), fakeDepsFactory{servingClient: servingClient})
+		o.RouteFlags = RouteFlags{cmdcore.NamespaceFlags{"ns1"}, "rt1"}
+		flags.WaitTimeout = 5 * time.Second
+		o.SwapFlags = flags
+		return o
+	}
+
+	err := newSwapOptions(SwapFlags{Blue: "srv1-00001", Green: "srv1-00002"}).Run()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	expectedTraffic := []v1alpha1.TrafficTarget{{RevisionName: "srv1-00002", Percent: 100}}
+
+	if !reflect.DeepEqual(servingClient.Route("ns1", "rt1").Spec.Traffic, expectedTraffic) {
+		t.Fatalf("Expected traffic '%#v' to equal '%#v'", servingClient.Route("ns1", "rt1").Spec.Traffic, expectedTraffic)
+	}
+
+	err = newSwapOptions(SwapFlags{Blue: "srv1-00001", Green: "srv1-00002"}).Run()
+	if err == nil || err.Error() != "Expected blue revision 'srv1-00001' to receive traffic from route 'rt1'" {
+		t.Fatalf("Expected error about blue revision but was '%v'", err)
+	}
+
+	err = newSwapOptions(SwapFlags{Undo: true}).Run()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if !reflect.DeepEqual(servingClient.Route("ns1", "rt1").Spec.Traffic, origTraffic) {
+		t.Fatalf("Expected traffic '%#v' to equal '%#v'", servingClient.Route("ns1", "rt1").Spec.Traffic, origTraffic)
+	}
+
+	if _, found := servingClient.Route("ns1", "rt1").Annotations[SwapPreviousTrafficAnnotationKey]; found {
+		t.Fatalf("Expected previous traffic annotation to be removed")
+	}
+
+	err = newSwapOptions(SwapFlags{Undo: true}).Run()
+	if err == nil || err.Error() != "Expected route 'rt1' to have recorded traffic before last swap" {
+		t.Fatalf("Expected error about missing previous traffic but was '%v'", err)
+	}
+}
+
+func TestSwapFlagsValidation(t *testing.T) {
+	examples := []struct {
+		Flags       SwapFlags
+		ExpectedErr string
+	}{
+		{
+			Flags:       SwapFlags{Blue: "srv1:previous"},
+			ExpectedErr: "Expected '--blue' and '--green' flags to be specified (unless '--undo' flag is used)",
+		},
+		{
+			Flags:       SwapFlags{Green: "srv1:latest", Undo: true},
+			ExpectedErr: "Expected '--blue' and '--green' flags to not be used with '--undo' flag",
+		},
+	}
+
+	for _, ex := range examples {
+		o := NewSwapOptions(ui.NewNoopUI(), fakeDepsFactory{servingClient: fakes.NewServingClient()})
+		o.SwapFlags = ex.Flags
+
+		err := o.Run()
+		if err == nil || err.Error() != ex.ExpectedErr {
+			t.Fatalf("Expected error '%s' but was '%v'", ex.ExpectedErr, err)
+		}
+	}
+}
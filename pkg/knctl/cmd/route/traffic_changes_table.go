@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"github.com/cppforlife/go-cli-ui/ui"
+	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+type TrafficChangesTable struct {
+	before []v1alpha1.TrafficTarget
+	after  []v1alpha1.TrafficTarget
+}
+
+func NewTrafficChangesTable(before, after []v1alpha1.TrafficTarget) TrafficChangesTable {
+	return TrafficChangesTable{before, after}
+}
+
+func (t TrafficChangesTable) Print(ui ui.UI) {
+	table := uitable.Table{
+		Title: "Traffic changes",
+		// TODO Content: "targets",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Revision"),
+			uitable.NewHeader("Service"),
+			uitable.NewHeader("Name"),
+			uitable.NewHeader("Before"),
+			uitable.NewHeader("After"),
+		},
+	}
+
+	for _, row := range t.rows() {
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueString(row.target.RevisionName),
+			uitable.NewValueString(row.target.ConfigurationName),
+			uitable.NewValueString(row.target.Name),
+			uitable.NewValueSuffix(uitable.NewValueInt(row.before), "%"),
+			uitable.NewValueSuffix(uitable.NewValueInt(row.after), "%"),
+		})
+	}
+
+	ui.PrintTable(table)
+}
+
+type trafficChangesRow struct {
+	target v1alpha1.TrafficTarget
+	before int
+	after  int
+}
+
+func (t TrafficChangesTable) rows() []*trafficChangesRow {
+	var rows []*trafficChangesRow
+	rowsByTarget := map[v1alpha1.TrafficTarget]*trafficChangesRow{}
+
+	rowFor := func(target v1alpha1.TrafficTarget) *trafficChangesRow {
+		key := v1alpha1.TrafficTarget{Name: target.Name, RevisionName: target.RevisionName, ConfigurationName: target.ConfigurationName}
+		if row, found := rowsByTarget[key]; found {
+			return row
+		}
+		row := &trafficChangesRow{target: key}
+		rowsByTarget[key] = row
+		rows = append(rows, row)
+		return row
+	}
+
+	for _, target := range t.before {
+		rowFor(target).before += target.Percent
+	}
+
+	for _, target := range t.after {
+		rowFor(target).after += target.Percent
+	}
+
+	return rows
+}
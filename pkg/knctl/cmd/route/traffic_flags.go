@@ -31,6 +31,6 @@ type TrafficFlags struct {
 func (s *TrafficFlags) Set(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory) {
 	s.GenerateNameFlags.Set(cmd, flagsFactory)
 
-	cmd.Flags().StringSliceVarP(&s.RevisionPercentages, "percentage", "p", nil, "Set revision percentage (format: revision=percentage, example: app-00001=100%, app:latest=100%) (can be specified multiple times)")
-	cmd.Flags().StringSliceVar(&s.ServicePercentages, "service-percentage", nil, "Set service percentage (format: service=percentage, example: app=100%) (can be specified multiple times)")
+	cmd.Flags().StringSliceVarP(&s.RevisionPercentages, "percentage", "p", nil, "Set revision percentage (format: revision=percentage[@name], example: app-00001=100%, app:latest=0%@candidate) (can be specified multiple times)")
+	cmd.Flags().StringSliceVar(&s.ServicePercentages, "service-percentage", nil, "Set service percentage (format: service=percentage[@name], example: app=100%) (can be specified multiple times)")
 }
@@ -0,0 +1,80 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"fmt"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+// ValidateTrafficTargets catches mistakes that would otherwise
+// be reported by Knative webhook when route is saved
+func ValidateTrafficTargets(targets []v1alpha1.TrafficTarget) error {
+	// Same revision or service may be specified again as a named target
+	unnamedTargets := map[v1alpha1.TrafficTarget]struct{}{}
+	names := map[string]struct{}{}
+	var percentSum int
+
+	for _, target := range targets {
+		if len(target.Name) > 0 {
+			if _, found := names[target.Name]; found {
+				return fmt.Errorf("Expected target name '%s' to be specified only once", target.Name)
+			}
+			names[target.Name] = struct{}{}
+		} else {
+			key := v1alpha1.TrafficTarget{RevisionName: target.RevisionName, ConfigurationName: target.ConfigurationName}
+
+			if _, found := unnamedTargets[key]; found {
+				if len(target.RevisionName) > 0 {
+					return fmt.Errorf("Expected revision '%s' to be specified only once", target.RevisionName)
+				}
+				return fmt.Errorf("Expected service '%s' to be specified only once", target.ConfigurationName)
+			}
+			unnamedTargets[key] = struct{}{}
+		}
+
+		percentSum += target.Percent
+	}
+
+	if percentSum != 100 {
+		return fmt.Errorf("Expected traffic percentages to add up to 100%% but they add up to %d%%", percentSum)
+	}
+
+	return nil
+}
+
+// TrafficTargetsApplied checks that route status traffic (always specified with revision names)
+// reflects given spec traffic. Traffic sent to services is assigned to their latest ready revisions,
+// hence revisions may receive more traffic than specified for them explicitly.
+func TrafficTargetsApplied(statusTargets, specTargets []v1alpha1.TrafficTarget) bool {
+	statusPercents := map[string]int{}
+	var statusSum int
+
+	for _, target := range statusTargets {
+		statusPercents[target.RevisionName] += target.Percent
+		statusSum += target.Percent
+	}
+
+	for _, target := range specTargets {
+		if len(target.RevisionName) > 0 && statusPercents[target.RevisionName] < target.Percent {
+			return false
+		}
+	}
+
+	return statusSum == 100
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route_test
+
+import (
+	"testing"
+
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/route"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+func TestValidateTrafficTargets(t *testing.T) {
+	examples := []struct {
+		Desc        string
+		Targets     []v1alpha1.TrafficTarget
+		ExpectedErr string
+	}{
+		{
+			Desc: "valid",
+			Targets: []v1alpha1.TrafficTarget{
+				{RevisionName: "srv1-00001", Percent: 20},
+				{RevisionName: "srv1-00002", Percent: 30},
+				{ConfigurationName: "srv1", Percent: 50},
+			},
+		},
+		{
+			Desc:        "no targets",
+			ExpectedErr: "Expected traffic percentages to add up to 100% but they add up to 0%",
+		},
+		{
+			Desc: "sum below 100",
+			Targets: []v1alpha1.TrafficTarget{
+				{RevisionName: "srv1-00001", Percent: 20},
+				{RevisionName: "srv1-00002", Percent: 70},
+			},
+			ExpectedErr: "Expected traffic percentages to add up to 100% but they add up to 90%",
+		},
+		{
+			Desc: "duplicate revision",
+			Targets: []v1alpha1.TrafficTarget{
+				{RevisionName: "srv1-00001", Percent: 50},
+				{RevisionName: "srv1-00001", Percent: 50},
+			},
+			ExpectedErr: "Expected revision 'srv1-00001' to be specified only once",
+		},
+		{
+			Desc: "named revision specified again",
+			Targets: []v1alpha1.TrafficTarget{
+				{RevisionName: "srv1-00001", Percent: 100},
+				{RevisionName: "srv1-00001", Percent: 0, Name: "candidate"},
+			},
+		},
+		{
+			Desc: "duplicate target name",
+			Targets: []v1alpha1.TrafficTarget{
+				{RevisionName: "srv1-00001", Percent: 100, Name: "candidate"},
+				{RevisionName: "srv1-00002", Percent: 0, Name: "candidate"},
+			},
+			ExpectedErr: "Expected target name 'candidate' to be specified only once",
+		},
+		{
+			Desc: "duplicate service",
+			Targets: []v1alpha1.TrafficTarget{
+				{ConfigurationName: "srv1", Percent: 50},
+				{ConfigurationName: "srv1", Percent: 50},
+			},
+			ExpectedErr: "Expected service 'srv1' to be specified only once",
+		},
+	}
+
+	for _, ex := range examples {
+		err := ValidateTrafficTargets(ex.Targets)
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("[%s] Expected error '%s' but was '%v'", ex.Desc, ex.ExpectedErr, err)
+			}
+		} else if err != nil {
+			t.Fatalf("[%s] Expected no error: %s", ex.Desc, err)
+		}
+	}
+}
+
+func TestTrafficTargetsApplied(t *testing.T) {
+	specTargets := []v1alpha1.TrafficTarget{
+		{RevisionName: "srv1-00001", Percent: 20},
+		{ConfigurationName: "srv1", Percent: 80},
+	}
+
+	examples := []struct {
+		Desc           string
+		StatusTargets  []v1alpha1.TrafficTarget
+		ExpectedResult bool
+	}{
+		{
+			Desc: "applied",
+			StatusTargets: []v1alpha1.TrafficTarget{
+				{RevisionName: "srv1-00001", Percent: 20},
+				{RevisionName: "srv1-00002", Percent: 80},
+			},
+			ExpectedResult: true,
+		},
+		{
+			Desc: "applied with service pointing to the same revision",
+			StatusTargets: []v1alpha1.TrafficTarget{
+				{RevisionName: "srv1-00001", Percent: 20},
+				{RevisionName: "srv1-00001", Percent: 80},
+			},
+			ExpectedResult: true,
+		},
+		{
+			Desc: "previous traffic",
+			StatusTargets: []v1alpha1.TrafficTarget{
+				{RevisionName: "srv1-00002", Percent: 100},
+			},
+			ExpectedResult: false,
+		},
+		{
+			Desc:           "no traffic",
+			ExpectedResult: false,
+		},
+	}
+
+	for _, ex := range examples {
+		result := TrafficTargetsApplied(ex.StatusTargets, specTargets)
+		if result != ex.ExpectedResult {
+			t.Fatalf("[%s] Expected result '%t' but was '%t'", ex.Desc, ex.ExpectedResult, result)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Browser opens URLs in a web browser. $BROWSER environment variable
+// (colon separated list of commands, first one is used) takes precedence
+// over OS specific default command.
+type Browser struct {
+	goos       string
+	browserEnv string
+}
+
+func NewBrowser() Browser {
+	return NewBrowserForOS(runtime.GOOS, os.Getenv("BROWSER"))
+}
+
+func NewBrowserForOS(goos, browserEnv string) Browser {
+	return Browser{goos, browserEnv}
+}
+
+func (b Browser) Command(url string) []string {
+	if len(b.browserEnv) > 0 {
+		cmd := strings.Split(b.browserEnv, ":")[0]
+
+		if strings.Contains(cmd, "%s") {
+			return strings.Fields(strings.Replace(cmd, "%s", url, -1))
+		}
+		return append(strings.Fields(cmd), url)
+	}
+
+	switch b.goos {
+	case "darwin":
+		return []string{"open", url}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler", url}
+	default:
+		return []string{"xdg-open", url}
+	}
+}
+
+func (b Browser) Open(url string) error {
+	args := b.Command(url)
+
+	err := exec.Command(args[0], args[1:]...).Start()
+	if err != nil {
+		return fmt.Errorf("Starting browser (set $BROWSER to configure browser command): %s", err)
+	}
+
+	return nil
+}
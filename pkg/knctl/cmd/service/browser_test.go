@@ -0,0 +1,46 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/service"
+)
+
+func TestBrowserCommand(t *testing.T) {
+	examples := []struct {
+		Browser  Browser
+		Expected []string
+	}{
+		{NewBrowserForOS("darwin", ""), []string{"open", "http://url"}},
+		{NewBrowserForOS("linux", ""), []string{"xdg-open", "http://url"}},
+		{NewBrowserForOS("freebsd", ""), []string{"xdg-open", "http://url"}},
+		{NewBrowserForOS("windows", ""), []string{"rundll32", "url.dll,FileProtocolHandler", "http://url"}},
+		{NewBrowserForOS("linux", "firefox"), []string{"firefox", "http://url"}},
+		{NewBrowserForOS("darwin", "firefox --new-tab:chrome"), []string{"firefox", "--new-tab", "http://url"}},
+		{NewBrowserForOS("linux", "chrome --app=%s"), []string{"chrome", "--app=http://url"}},
+	}
+
+	for _, ex := range examples {
+		cmd := ex.Browser.Command("http://url")
+		if !reflect.DeepEqual(cmd, ex.Expected) {
+			t.Fatalf("Expected command '%#v' to equal '%#v'", cmd, ex.Expected)
+		}
+	}
+}
@@ -17,13 +17,10 @@ limitations under the License.
 package service
 
 import (
-	"fmt"
-	"os/exec"
-	"strings"
-
 	"github.com/cppforlife/go-cli-ui/ui"
 	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
 	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	ctlcurl "github.com/cppforlife/knctl/pkg/knctl/curl"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -32,9 +29,10 @@ type CurlOptions struct {
 	ui          ui.UI
 	depsFactory cmdcore.DepsFactory
 
-	ServiceFlags cmdflags.ServiceFlags
-	CurlFlags    CurlFlags
-	Verbose      bool
+	ServiceFlags     cmdflags.ServiceFlags
+	CurlFlags        CurlFlags
+	HTTPRequestFlags cmdflags.HTTPRequestFlags
+	Verbose          bool
 }
 
 func NewCurlOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *CurlOptions {
@@ -45,12 +43,13 @@ func NewCurlCmd(o *CurlOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Comman
 	cmd := &cobra.Command{
 		Use:   "curl",
 		Short: "Curl service",
-		Long: `Send a HTTP request to the first ingress address with the Host header set to the service's domain.
-
-Requires 'curl' command installed on the system.`,
+		Long:  `Send a HTTP request to the first ingress address with the Host header set to the service's domain.`,
 		Example: `
   # Curl service 'svc1' in namespace 'ns1'
-  knctl curl -s svc1 -n ns1`,
+  knctl curl -s svc1 -n ns1
+
+  # Send POST request with JSON body to service 'svc1' in namespace 'ns1' and check response status
+  knctl curl -s svc1 -X POST -H 'Content-Type: application/json' -d '{"a":1}' --expect-status 200 -n ns1`,
 		Annotations: map[string]string{
 			cmdcore.BasicHelpGroup.Key: cmdcore.BasicHelpGroup.Value,
 		},
@@ -58,35 +57,25 @@ Requires 'curl' command installed on the system.`,
 	}
 	o.ServiceFlags.Set(cmd, flagsFactory)
 	o.CurlFlags.Set(cmd, flagsFactory)
-	cmd.Flags().BoolVarP(&o.Verbose, "verbose", "v", false, "Makes curl verbose during the operation")
+	o.HTTPRequestFlags.Set(cmd, flagsFactory)
+	cmd.Flags().BoolVarP(&o.Verbose, "verbose", "v", false, "Print request and response details")
 	return cmd
 }
 
 func (o *CurlOptions) Run() error {
-	domain, url, err := o.addr()
+	opts, err := o.HTTPRequestFlags.RequestOpts()
 	if err != nil {
 		return err
 	}
 
-	cmdName := "curl"
-	cmdArgs := []string{}
-
-	if o.Verbose {
-		cmdArgs = append(cmdArgs, "-vvv")
-	}
+	opts.Verbose = o.Verbose
 
-	cmdArgs = append(cmdArgs, []string{"-sS", "-H", "Host: " + domain, url}...)
-
-	o.ui.PrintLinef("Running: %s '%s'", cmdName, strings.Join(cmdArgs, "' '"))
-
-	out, err := exec.Command(cmdName, cmdArgs...).CombinedOutput()
+	domain, url, err := o.addr()
 	if err != nil {
-		return fmt.Errorf("Running curl: %s", err)
+		return err
 	}
 
-	o.ui.PrintBlock(out)
-
-	return nil
+	return ctlcurl.NewRequest(url, domain, opts).Run(o.ui)
 }
 
 func (o *CurlOptions) addr() (string, string, error) {
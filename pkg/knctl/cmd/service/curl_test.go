@@ -18,6 +18,7 @@ package service_test
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/cppforlife/knctl/pkg/knctl/cmd"
 	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
@@ -33,6 +34,13 @@ func TestNewCurlCmd_Ok(t *testing.T) {
 		"-n", "test-namespace",
 		"-s", "test-service",
 		"-p", "1234",
+		"-X", "PUT",
+		"-H", "X-A: 1",
+		"-H", "Accept: text/html, application/json",
+		"-d", "test-data",
+		"-i",
+		"--expect-status", "201",
+		"--timeout", "5s",
 		"-v",
 	})
 	cmd.ExpectReachesExecution()
@@ -40,6 +48,14 @@ func TestNewCurlCmd_Ok(t *testing.T) {
 	DeepEqual(t, realCmd.ServiceFlags,
 		cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-service"})
 	DeepEqual(t, realCmd.CurlFlags, CurlFlags{Port: int32(1234)})
+	DeepEqual(t, realCmd.HTTPRequestFlags, cmdflags.HTTPRequestFlags{
+		Method:       "PUT",
+		Headers:      []string{"X-A: 1", "Accept: text/html, application/json"},
+		Data:         "test-data",
+		Timeout:      5 * time.Second,
+		Include:      true,
+		ExpectStatus: 201,
+	})
 	DeepEqual(t, realCmd.Verbose, true)
 }
 
@@ -50,6 +66,10 @@ func TestNewCurlCmd_OkLongFlagNames(t *testing.T) {
 		"--namespace", "test-namespace",
 		"--service", "test-service",
 		"--port", "1234",
+		"--request", "PUT",
+		"--header", "X-A: 1",
+		"--data-file", "/tmp/data",
+		"--include",
 		"--verbose",
 	})
 	cmd.ExpectReachesExecution()
@@ -57,6 +77,13 @@ func TestNewCurlCmd_OkLongFlagNames(t *testing.T) {
 	DeepEqual(t, realCmd.ServiceFlags,
 		cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-service"})
 	DeepEqual(t, realCmd.CurlFlags, CurlFlags{Port: int32(1234)})
+	DeepEqual(t, realCmd.HTTPRequestFlags, cmdflags.HTTPRequestFlags{
+		Method:   "PUT",
+		Headers:  []string{"X-A: 1"},
+		DataFile: "/tmp/data",
+		Timeout:  30 * time.Second,
+		Include:  true,
+	})
 	DeepEqual(t, realCmd.Verbose, true)
 }
 
@@ -72,6 +99,7 @@ func TestNewCurlCmd_OkMinimum(t *testing.T) {
 	DeepEqual(t, realCmd.ServiceFlags,
 		cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-service"})
 	DeepEqual(t, realCmd.CurlFlags, CurlFlags{Port: int32(80)})
+	DeepEqual(t, realCmd.HTTPRequestFlags, cmdflags.HTTPRequestFlags{Timeout: 30 * time.Second})
 	DeepEqual(t, realCmd.Verbose, false)
 }
 
@@ -17,6 +17,7 @@ limitations under the License.
 package service
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/cppforlife/go-cli-ui/ui"
@@ -76,23 +77,88 @@ func NewDeployCmd(o *DeployOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Co
   knctl deploy -s srv1 -n ns1 \
       --image gcr.io/knative-samples/helloworld-go \
       --env-secret TARGET=secret/key1 \
-      --env-secret TARGET=secret/key2`,
+      --env-secret TARGET=secret/key2
+
+  # Deploy service 'srv1' with custom entrypoint in namespace 'ns1'
+  knctl deploy -s srv1 -n ns1 \
+      --image gcr.io/your-account/your-image \
+      --command /app/server --arg=--verbose
+
+  # Deploy service 'srv1' with HTTP readiness and TCP liveness probes in namespace 'ns1'
+  knctl deploy -s srv1 -n ns1 \
+      --image gcr.io/knative-samples/helloworld-go \
+      --readiness-http-path /healthz --readiness-period 5s --readiness-failure-threshold 3 \
+      --liveness-tcp --liveness-initial-delay 10s
+
+  # Deploy service 'srv1' with environment variables from a file, a secret and a config map in namespace 'ns1'
+  # ( https://github.com/cppforlife/knctl/blob/master/docs/deploy-secrets.md )
+  knctl deploy -s srv1 -n ns1 \
+      --image gcr.io/knative-samples/helloworld-go \
+      --env-file .env --env-from-secret secret1 --env-from-config-map config-map1
+
+  # Deploy service described in a deploy manifest, overriding its image, in namespace 'ns1'
+  # ( https://github.com/cppforlife/knctl/blob/master/docs/deploy-manifest.md )
+  knctl deploy -f app.yml --image gcr.io/knative-samples/helloworld-go -n ns1
+
+  # Deploy service 'srv1' creating a new revision even if nothing has changed in namespace 'ns1'
+  knctl deploy -s srv1 --image gcr.io/knative-samples/helloworld-go --force -n ns1
+
+  # Deploy service 'srv1' rolling back to previous revision if new revision does not become ready in namespace 'ns1'
+  knctl deploy -s srv1 --image gcr.io/knative-samples/helloworld-go --rollback-on-failure -n ns1
+
+  # Deploy service 'srv1' sending 10% of traffic to new revision (and 90% to current revision) in namespace 'ns1'
+  # ( https://github.com/cppforlife/knctl/blob/master/docs/release.md )
+  knctl deploy -s srv1 --image gcr.io/knative-samples/helloworld-go --release --rollout-percent 10 -n ns1
+
+  # Show what would change for service 'srv1' in namespace 'ns1' without deploying
+  knctl deploy -s srv1 --image gcr.io/knative-samples/helloworld-go --env TARGET=123 --dry-run -n ns1`,
 		Annotations: map[string]string{
 			cmdcore.BasicHelpGroup.Key: cmdcore.BasicHelpGroup.Value,
 		},
 		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
 	}
-	o.ServiceFlags.Set(cmd, flagsFactory)
+	o.ServiceFlags.SetOptional(cmd, flagsFactory)
 	o.DeployFlags.Set(cmd, flagsFactory)
 	return cmd
 }
 
 func (o *DeployOptions) Run() error {
+	err := o.applyManifest()
+	if err != nil {
+		return err
+	}
+
+	err = o.validateFlags()
+	if err != nil {
+		return err
+	}
+
+	serviceSpec := NewServiceSpec(o.ServiceFlags, o.DeployFlags)
+
+	// Catch invalid values (e.g. malformed probe settings) before making any API calls
+	_, err = serviceSpec.Configuration()
+	if err != nil {
+		return err
+	}
+
 	servingClient, err := o.depsFactory.ServingClient()
 	if err != nil {
 		return err
 	}
 
+	if o.DeployFlags.Release {
+		currentRevisionName, err := o.release(servingClient).CurrentRevisionName()
+		if err != nil {
+			return err
+		}
+
+		serviceSpec = serviceSpec.WithReleaseRevisions([]string{currentRevisionName})
+	}
+
+	if o.DeployFlags.DryRun {
+		return o.dryRun(serviceSpec, servingClient)
+	}
+
 	buildClient, err := o.depsFactory.BuildClient()
 	if err != nil {
 		return err
@@ -103,12 +169,17 @@ func (o *DeployOptions) Run() error {
 		return err
 	}
 
+	// Warnings are not shown for dry runs since they only need serving client
+	err = o.printWarnings(serviceSpec, coreClient)
+	if err != nil {
+		return err
+	}
+
 	restConfig, err := o.configFactory.RESTConfig()
 	if err != nil {
 		return err
 	}
 
-	serviceSpec := NewServiceSpec(o.ServiceFlags, o.DeployFlags)
 	buildObjFactory := ctlbuild.NewFactory(buildClient, coreClient, restConfig)
 	serviceObj := ctlservice.NewService(serviceSpec, servingClient, buildClient, coreClient, buildObjFactory)
 
@@ -122,13 +193,32 @@ func (o *DeployOptions) Run() error {
 		}
 	}
 
-	createdService, err := serviceObj.CreateOrUpdate()
+	// Build source (e.g. uploaded directory) may change without changes to the spec
+	force := o.DeployFlags.Force || serviceSpec.HasBuild()
+
+	if !o.DeployFlags.ForceTags && !o.DeployFlags.GenerateNameFlags.GenerateName {
+		needsUpdate, err := serviceObj.NeedsUpdate(force)
+		if err != nil {
+			return err
+		}
+
+		err = o.checkProtectedTags(lastRevision, needsUpdate, servingClient)
+		if err != nil {
+			return err
+		}
+	}
+
+	createdService, updated, err := serviceObj.CreateOrUpdate(force)
 	if err != nil {
 		return err
 	}
 
 	o.printTable(createdService)
 
+	if !updated {
+		return o.noChanges(lastRevision, servingClient)
+	}
+
 	if lastRevision != nil {
 		o.ui.PrintLinef("Waiting for new revision (after revision '%s') to be created...", lastRevision.Name)
 	} else {
@@ -150,6 +240,13 @@ func (o *DeployOptions) Run() error {
 		return err
 	}
 
+	if o.DeployFlags.Release {
+		err = o.releaseCandidate(newLastRevision, servingClient)
+		if err != nil {
+			return err
+		}
+	}
+
 	// TODO support non Knative builders
 	if serviceSpec.HasBuild() {
 		cancelCh := make(chan struct{})
@@ -173,17 +270,144 @@ func (o *DeployOptions) Run() error {
 
 		err = buildObj.Error(cancelCh)
 		if err != nil {
-			return err
+			return o.rollbackOnFailure(err, lastRevision, newLastRevision, serviceObj, servingClient)
 		}
 	}
 
 	if o.DeployFlags.WatchRevisionReady {
-		return o.watchRevisionReady(newLastRevision, servingClient, coreClient)
+		ready, err := o.watchRevisionReady(newLastRevision, servingClient, coreClient)
+		if err != nil {
+			return err
+		}
+
+		if !ready && o.DeployFlags.RollbackOnFailure {
+			err := fmt.Errorf("Expected revision '%s' to become ready", newLastRevision.Name)
+			return o.rollbackOnFailure(err, lastRevision, newLastRevision, serviceObj, servingClient)
+		}
+	}
+
+	return nil
+}
+
+func (o *DeployOptions) validateFlags() error {
+	if o.DeployFlags.RollbackOnFailure && !o.DeployFlags.WatchRevisionReady {
+		return fmt.Errorf("Expected '--watch-revision-ready' to be enabled when using '--rollback-on-failure'")
+	}
+
+	if o.DeployFlags.RolloutPercent != nil {
+		if !o.DeployFlags.Release {
+			return fmt.Errorf("Expected '--rollout-percent' to be used with '--release'")
+		}
+
+		err := ctlservice.ValidateReleaseRolloutPercent(*o.DeployFlags.RolloutPercent)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.DeployFlags.Release && o.DeployFlags.GenerateNameFlags.GenerateName {
+		return fmt.Errorf("Expected '--release' to not be used with '--generate-name'")
+	}
+
+	return nil
+}
+
+func (o *DeployOptions) applyManifest() error {
+	var manifest DeployManifest
+
+	if len(o.DeployFlags.ManifestPath) > 0 {
+		var err error
+
+		manifest, err = NewDeployManifestFromPath(o.DeployFlags.ManifestPath)
+		if err != nil {
+			return err
+		}
+
+		manifest.Apply(&o.ServiceFlags, &o.DeployFlags)
+	}
+
+	if len(o.ServiceFlags.Name) == 0 {
+		return manifest.errorf("service", "Expected service name to be specified via '--service' flag or deploy manifest")
+	}
+	if len(o.DeployFlags.Image) == 0 {
+		return manifest.errorf("image", "Expected image to be specified via '--image' flag or deploy manifest")
+	}
+
+	return nil
+}
+
+func (o *DeployOptions) printWarnings(serviceSpec ServiceSpec, coreClient kubernetes.Interface) error {
+	warnings, err := serviceSpec.EnvWarnings(coreClient)
+	if err != nil {
+		return err
+	}
+
+	pullWarnings, err := serviceSpec.ImagePullWarnings(coreClient)
+	if err != nil {
+		return err
+	}
+
+	warnings = append(warnings, pullWarnings...)
+
+	for _, warning := range warnings {
+		o.ui.ErrorLinef("Warning: %s", warning)
 	}
 
 	return nil
 }
 
+func (o *DeployOptions) dryRun(serviceSpec ServiceSpec, servingClient servingclientset.Interface) error {
+	numChanges, err := NewDeployDryRun(serviceSpec, servingClient, o.ui).Run()
+	if err != nil {
+		return err
+	}
+
+	if numChanges > 0 {
+		return fmt.Errorf("Expected no changes, but found %d change(s) (dry run)", numChanges)
+	}
+
+	o.ui.PrintLinef("No changes (dry run)")
+
+	return nil
+}
+
+func (o *DeployOptions) noChanges(lastRevision *v1alpha1.Revision, servingClient servingclientset.Interface) error {
+	o.ui.PrintLinef("No changes to service '%s' (use '--force' to create new revision)", o.ServiceFlags.Name)
+
+	if lastRevision == nil {
+		return nil
+	}
+
+	tags := ctlservice.NewTags(servingClient).WithForce(o.DeployFlags.ForceTags)
+
+	for _, tag := range append([]string{ctlservice.TagsLatest}, o.DeployFlags.TagFlags.Tags...) {
+		o.ui.PrintLinef("Tagging revision '%s' as '%s'", lastRevision.Name, tag)
+
+		err := tags.Repoint(lastRevision, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return o.updateRevisionAnnotations(lastRevision, servingClient)
+}
+
+func (o *DeployOptions) release(servingClient servingclientset.Interface) ctlservice.ServiceRelease {
+	return ctlservice.NewServiceRelease(servingClient, o.ServiceFlags.NamespaceFlags.Name, o.ServiceFlags.Name)
+}
+
+func (o *DeployOptions) releaseCandidate(newLastRevision *v1alpha1.Revision, servingClient servingclientset.Interface) error {
+	var rolloutPercent int
+
+	if o.DeployFlags.RolloutPercent != nil {
+		rolloutPercent = *o.DeployFlags.RolloutPercent
+	}
+
+	o.ui.PrintLinef("Releasing new revision '%s' as candidate with %d%% of traffic", newLastRevision.Name, rolloutPercent)
+
+	return o.release(servingClient).SetCandidate(newLastRevision.Name, rolloutPercent)
+}
+
 func (o *DeployOptions) printTable(svc *v1alpha1.Service) {
 	table := uitable.Table{
 		Header: []uitable.Header{
@@ -203,7 +427,7 @@ func (o *DeployOptions) printTable(svc *v1alpha1.Service) {
 func (o *DeployOptions) updateRevisionTags(
 	lastRevision *v1alpha1.Revision, newLastRevision *v1alpha1.Revision, servingClient servingclientset.Interface) error {
 
-	tags := ctlservice.NewTags(servingClient)
+	tags := ctlservice.NewTags(servingClient).WithForce(o.DeployFlags.ForceTags)
 
 	for _, tag := range append([]string{ctlservice.TagsLatest}, o.DeployFlags.TagFlags.Tags...) {
 		o.ui.PrintLinef("Tagging new revision '%s' as '%s'", newLastRevision.Name, tag)
@@ -234,6 +458,62 @@ func (o *DeployOptions) updateRevisionTags(
 	return nil
 }
 
+// checkProtectedTags returns an error if protected tags would be moved by updateRevisionTags
+// (or noChanges when service is not updated). It runs before service is saved so that
+// deploy does not end up with new revision that has only some of the tags moved.
+func (o *DeployOptions) checkProtectedTags(
+	lastRevision *v1alpha1.Revision, updating bool, servingClient servingclientset.Interface) error {
+
+	protectedTags, err := ctlservice.NewTagProtection(
+		servingClient, o.ServiceFlags.NamespaceFlags.Name, o.ServiceFlags.Name).List()
+	if err != nil {
+		return err
+	}
+
+	// Revisions expected to hold tags after deploy; empty name stands for new revision
+	expectedHolders := map[string]string{}
+	movedTags := append([]string{ctlservice.TagsLatest}, o.DeployFlags.TagFlags.Tags...)
+
+	switch {
+	case updating:
+		for _, tag := range movedTags {
+			expectedHolders[tag] = ""
+		}
+		if lastRevision != nil {
+			expectedHolders[ctlservice.TagsPrevious] = lastRevision.Name
+		} else {
+			expectedHolders[ctlservice.TagsPrevious] = ""
+		}
+
+	case lastRevision != nil:
+		for _, tag := range movedTags {
+			expectedHolders[tag] = lastRevision.Name
+		}
+	}
+
+	tags := ctlservice.NewTags(servingClient)
+	findCtx := ctlservice.TagsFindContext{Namespace: o.ServiceFlags.NamespaceFlags.Name, Service: o.ServiceFlags.Name}
+
+	for _, tag := range protectedTags {
+		expectedHolderName, found := expectedHolders[tag]
+		if !found {
+			continue
+		}
+
+		holder, found, err := tags.TryFind(findCtx, tag)
+		if err != nil {
+			return err
+		}
+
+		if found && holder.Name != expectedHolderName {
+			return fmt.Errorf("Expected tag '%s' to not be moved from revision '%s' "+
+				"since it is protected (use '--force-tags' to move it)", tag, holder.Name)
+		}
+	}
+
+	return nil
+}
+
 func (o *DeployOptions) updateRevisionAnnotations(
 	newLastRevision *v1alpha1.Revision, servingClient servingclientset.Interface) error {
 
@@ -246,7 +526,7 @@ func (o *DeployOptions) updateRevisionAnnotations(
 		return nil
 	}
 
-	o.ui.PrintLinef("Annotating new revision '%s'", newLastRevision.Name)
+	o.ui.PrintLinef("Annotating revision '%s'", newLastRevision.Name)
 
 	anns := ctlkube.NewAnnotations(func(type_ types.PatchType, data []byte) error {
 		_, err := servingClient.ServingV1alpha1().Revisions(newLastRevision.Namespace).Patch(newLastRevision.Name, type_, data)
@@ -256,8 +536,44 @@ func (o *DeployOptions) updateRevisionAnnotations(
 	return anns.Add(annotations)
 }
 
+// rollbackOnFailure restores previous service spec (or pins traffic of unmanaged routes
+// to the previous revision) and re-points 'latest' tag to the revision serving traffic.
+// Returns given failure error unless rollback is not requested.
+func (o *DeployOptions) rollbackOnFailure(failureErr error, lastRevision, failedRevision *v1alpha1.Revision,
+	serviceObj *ctlservice.Service, servingClient servingclientset.Interface) error {
+
+	if !o.DeployFlags.RollbackOnFailure {
+		return failureErr
+	}
+
+	if lastRevision == nil {
+		return fmt.Errorf("%s (no previous revision to roll back to)", failureErr)
+	}
+
+	o.ui.PrintLinef("Rolling back to revision '%s'", lastRevision.Name)
+
+	rollback, err := serviceObj.Rollback(lastRevision, failedRevision)
+	if err != nil {
+		return fmt.Errorf("%s (rolling back to revision '%s': %s)", failureErr, lastRevision.Name, err)
+	}
+
+	for _, routeName := range rollback.PinnedRouteNames {
+		o.ui.PrintLinef("Routing traffic of route '%s' to revision '%s'", routeName, lastRevision.Name)
+	}
+
+	if rollback.LatestRevision.Name != lastRevision.Name {
+		o.ui.PrintLinef("Restoring previous configuration of service '%s' as revision '%s'",
+			o.ServiceFlags.Name, rollback.LatestRevision.Name)
+	}
+
+	o.ui.PrintLinef("Tagging revision '%s' as '%s'", rollback.LatestRevision.Name, ctlservice.TagsLatest)
+
+	return fmt.Errorf("%s (rolled back to revision '%s')", failureErr, lastRevision.Name)
+}
+
+// watchRevisionReady returns true if revision became ready before timeout
 func (o *DeployOptions) watchRevisionReady(
-	newLastRevision *v1alpha1.Revision, servingClient servingclientset.Interface, coreClient kubernetes.Interface) error {
+	newLastRevision *v1alpha1.Revision, servingClient servingclientset.Interface, coreClient kubernetes.Interface) (bool, error) {
 
 	totalWaitDur := o.DeployFlags.WatchRevisionReadyTimeout
 	logCollectDur := 5 * time.Second
@@ -271,8 +587,19 @@ func (o *DeployOptions) watchRevisionReady(
 		close(cancelWatchCh)
 	}()
 
+	// No need to keep waiting for failed revision if it's going to be rolled back
+	// or its probes already explain why it's not ready
+	stopOnFailure := o.DeployFlags.RollbackOnFailure || o.hasProbes(newLastRevision)
+
+	watcher := NewRevisionReadyStatusWatcher(newLastRevision, servingClient, coreClient, o.ui).WithStopOnFailure(stopOnFailure)
+
+	var ready bool
+	readyDoneCh := make(chan struct{})
+
 	go func() {
-		ready, _ := RevisionReadyStatusWatcher{newLastRevision, servingClient}.Wait(cancelWatchCh)
+		ready, _ = watcher.Wait(cancelWatchCh)
+		close(readyDoneCh)
+
 		if ready {
 			o.ui.PrintLinef("Revision '%s' became ready", newLastRevision.Name)
 		} else {
@@ -298,11 +625,25 @@ func (o *DeployOptions) watchRevisionReady(
 
 		err := LogsView{tailOpts, podWatcher, coreClient, o.ui}.Show(cancelLogsCh)
 		if err != nil {
-			return err
+			return false, err
 		}
 	} else {
 		<-cancelLogsCh
 	}
 
-	return nil
+	<-readyDoneCh
+
+	if !ready {
+		conds, err := watcher.FailingConditions()
+		if err == nil && len(conds) > 0 {
+			cmdcore.NewConditionsTable(conds).Print(o.ui)
+		}
+	}
+
+	return ready, nil
+}
+
+func (o *DeployOptions) hasProbes(revision *v1alpha1.Revision) bool {
+	container := revision.Spec.Container
+	return container.ReadinessProbe != nil || container.LivenessProbe != nil
 }
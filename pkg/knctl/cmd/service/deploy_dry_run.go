@@ -0,0 +1,175 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	ctldiff "github.com/cppforlife/knctl/pkg/knctl/diff"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeployDryRun compares desired service (and configuration for unmanaged routes)
+// against live objects without making any changes
+type DeployDryRun struct {
+	serviceSpec   ServiceSpec
+	servingClient servingclientset.Interface
+	ui            ui.UI
+}
+
+func NewDeployDryRun(serviceSpec ServiceSpec, servingClient servingclientset.Interface, ui ui.UI) DeployDryRun {
+	return DeployDryRun{serviceSpec, servingClient, ui}
+}
+
+// Run returns number of found changes
+func (d DeployDryRun) Run() (int, error) {
+	service, err := d.serviceSpec.Service()
+	if err != nil {
+		return 0, err
+	}
+
+	var liveService *v1alpha1.Service
+
+	// Generated names always result in a new service
+	if len(service.Name) > 0 {
+		liveService, err = d.servingClient.ServingV1alpha1().Services(service.Namespace).Get(service.Name, metav1.GetOptions{})
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return 0, fmt.Errorf("Getting service: %s", err)
+			}
+			liveService = nil
+		}
+	}
+
+	serviceDiff, err := d.serviceDiff(service, liveService)
+	if err != nil {
+		return 0, err
+	}
+
+	d.printDiff(fmt.Sprintf("Service '%s' changes", d.serviceSpec.Name()), liveService == nil, serviceDiff)
+
+	numChanges := len(serviceDiff.Changes())
+
+	if d.serviceSpec.NeedsConfigurationUpdate() {
+		conf, err := d.serviceSpec.Configuration()
+		if err != nil {
+			return 0, err
+		}
+
+		var liveConf *v1alpha1.Configuration
+
+		if liveService != nil {
+			liveConf, err = d.servingClient.ServingV1alpha1().Configurations(service.Namespace).Get(service.Name, metav1.GetOptions{})
+			if err != nil {
+				if !errors.IsNotFound(err) {
+					return 0, fmt.Errorf("Getting configuration: %s", err)
+				}
+				liveConf = nil
+			}
+		}
+
+		confDiff, err := d.confDiff(conf, liveConf)
+		if err != nil {
+			return 0, err
+		}
+
+		d.printDiff(fmt.Sprintf("Configuration '%s' changes", d.serviceSpec.Name()), liveConf == nil, confDiff)
+
+		numChanges += len(confDiff.Changes())
+	}
+
+	return numChanges, nil
+}
+
+func (d DeployDryRun) serviceDiff(service v1alpha1.Service, liveService *v1alpha1.Service) (ctldiff.FieldDiff, error) {
+	service.SetDefaults()
+	d.withoutKnctlAnnotations(&service.Spec)
+
+	if liveService == nil {
+		return ctldiff.NewFieldDiff(nil, service.Spec)
+	}
+
+	liveSpec := liveService.Spec.DeepCopy()
+	d.withoutKnctlAnnotations(liveSpec)
+
+	// Generation is managed by the server
+	service.Spec.Generation = liveSpec.Generation
+
+	return ctldiff.NewFieldDiff(liveSpec, service.Spec)
+}
+
+func (d DeployDryRun) confDiff(conf v1alpha1.Configuration, liveConf *v1alpha1.Configuration) (ctldiff.FieldDiff, error) {
+	conf.SetDefaults()
+	ctlservice.WithoutRevisionTemplateAnnotations(&conf.Spec)
+
+	if liveConf == nil {
+		return ctldiff.NewFieldDiff(nil, conf.Spec)
+	}
+
+	liveSpec := liveConf.Spec.DeepCopy()
+	ctlservice.WithoutRevisionTemplateAnnotations(liveSpec)
+
+	// Generation is managed by the server
+	conf.Spec.Generation = liveSpec.Generation
+
+	return ctldiff.NewFieldDiff(liveSpec, conf.Spec)
+}
+
+// withoutKnctlAnnotations removes annotations that are added during deploy
+func (DeployDryRun) withoutKnctlAnnotations(spec *v1alpha1.ServiceSpec) {
+	if confSpec := ctlservice.ServiceConfigurationSpec(spec); confSpec != nil {
+		ctlservice.WithoutRevisionTemplateAnnotations(confSpec)
+	}
+}
+
+func (d DeployDryRun) printDiff(title string, isNew bool, diff ctldiff.FieldDiff) {
+	if isNew {
+		title += " (new)"
+	}
+
+	table := uitable.Table{
+		Title:   title,
+		Content: "changes",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Field"),
+			uitable.NewHeader("Change"),
+			uitable.NewHeader("Live value"),
+			uitable.NewHeader("Desired value"),
+		},
+	}
+
+	for _, change := range diff.Changes() {
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueString(change.Path),
+			uitable.ValueFmt{
+				V:     uitable.NewValueString(string(change.Type)),
+				Error: change.Type == ctldiff.FieldChangeRemoved,
+			},
+			uitable.NewValueString(change.OldValue),
+			uitable.NewValueString(change.NewValue),
+		})
+	}
+
+	d.ui.PrintTable(table)
+}
@@ -32,24 +32,44 @@ type DeployFlags struct {
 	TagFlags             cmdflags.TagFlags
 	AnnotateFlags        cmdflags.AnnotateFlags
 
+	ManifestPath string
+
+	ServiceAccountName string
+
 	Image         string
 	EnvVars       []string
 	EnvSecrets    []string
 	EnvConfigMaps []string
 
+	EnvFiles          []string
+	EnvFromSecrets    []string
+	EnvFromConfigMaps []string
+
+	Command []string
+	Args    []string
+
+	ReadinessProbeFlags ProbeFlags
+	LivenessProbeFlags  ProbeFlags
+
 	ContainerConcurrency *int
 	MinScale             *int
 	MaxScale             *int
 
 	WatchRevisionReady        bool
 	WatchRevisionReadyTimeout time.Duration
+	RollbackOnFailure         bool
 
 	WatchPodLogs             bool
 	WatchPodLogsIndefinitely bool
 
 	ManagedRoute bool
 
-	RemoveKnctlDeployEnvVar bool
+	Release        bool
+	RolloutPercent *int
+
+	DryRun    bool
+	Force     bool
+	ForceTags bool
 }
 
 func (s *DeployFlags) Set(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory) {
@@ -58,14 +78,18 @@ func (s *DeployFlags) Set(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory)
 	s.TagFlags.Set(cmd, flagsFactory)
 	s.AnnotateFlags.Set(cmd, flagsFactory)
 
-	// TODO separate service account for pulling?
+	cmd.Flags().StringVarP(&s.ManifestPath, "file", "f", "", "Set deploy manifest path (flags take precedence over manifest values)")
 
-	cmd.Flags().StringVarP(&s.Image, "image", "i", "", "Set image URL")
-	cmd.MarkFlagRequired("image")
+	cmd.Flags().StringVarP(&s.Image, "image", "i", "", "Set image URL (required unless specified in deploy manifest)")
+
+	cmd.Flags().StringVar(&s.ServiceAccountName, "service-account", "",
+		"Set service account name for running (and pulling image); defaults to build service account if not specified")
 
 	cmd.Flags().BoolVar(&s.WatchRevisionReady, "watch-revision-ready", true, "Wait for new revision to become ready")
 	cmd.Flags().DurationVar(&s.WatchRevisionReadyTimeout, "watch-revision-ready-timeout",
 		5*time.Minute, "Set timeout for waiting for new revision to become ready")
+	cmd.Flags().BoolVar(&s.RollbackOnFailure, "rollback-on-failure", false,
+		"Roll back to previous revision if new revision fails or does not become ready in time (exits with an error)")
 
 	cmd.Flags().BoolVar(&s.WatchPodLogs, "watch-pod-logs", true, "Watch pod logs for new revision")
 	cmd.Flags().BoolVarP(&s.WatchPodLogsIndefinitely, "watch-pod-logs-indefinitely", "l",
@@ -75,11 +99,28 @@ func (s *DeployFlags) Set(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory)
 	cmd.Flags().StringSliceVar(&s.EnvSecrets, "env-secret", nil, "Set environment variable from a secret (format: ENV_KEY=secret-name/key) (can be specified multiple times)")
 	cmd.Flags().StringSliceVar(&s.EnvConfigMaps, "env-config-map", nil, "Set environment variable from a config map (format: ENV_KEY=config-map-name/key) (can be specified multiple times)")
 
+	cmd.Flags().StringArrayVar(&s.EnvFiles, "env-file", nil, "Set environment variables from a file in dotenv format (can be specified multiple times; later files take precedence)")
+	cmd.Flags().StringSliceVar(&s.EnvFromSecrets, "env-from-secret", nil, "Set environment variables from all keys of a secret (can be specified multiple times)")
+	cmd.Flags().StringSliceVar(&s.EnvFromConfigMaps, "env-from-config-map", nil, "Set environment variables from all keys of a config map (can be specified multiple times)")
+
+	cmd.Flags().StringArrayVar(&s.Command, "command", nil, "Set container entrypoint overriding image's entrypoint (can be specified multiple times, one per command element)")
+	cmd.Flags().StringArrayVar(&s.Args, "arg", nil, "Set container argument (can be specified multiple times)")
+
+	s.ReadinessProbeFlags.SetWithPrefix("readiness", cmd)
+	s.LivenessProbeFlags.SetWithPrefix("liveness", cmd)
+
 	cmd.Flags().Var(newDefaultlessIntValue(&s.ContainerConcurrency), "container-concurrency", "Set container concurrency")
 	cmd.Flags().Var(newDefaultlessIntValue(&s.MinScale), "min-scale", "Set autoscaling rule for minimum number of containers")
 	cmd.Flags().Var(newDefaultlessIntValue(&s.MaxScale), "max-scale", "Set autoscaling rule for maximum number of containers")
 
 	cmd.Flags().BoolVar(&s.ManagedRoute, "managed-route", true, "Custom route configuration")
+
+	cmd.Flags().BoolVar(&s.Release, "release", false, "Deploy new revision as a candidate next to current revision (uses service release mode)")
+	cmd.Flags().Var(newDefaultlessIntValue(&s.RolloutPercent), "rollout-percent", "Set percentage of traffic sent to candidate revision (0-99) (requires '--release')")
+
+	cmd.Flags().BoolVar(&s.Force, "force", false, "Create new revision even if nothing has changed")
+	cmd.Flags().BoolVar(&s.ForceTags, "force-tags", false, "Move tags even if they are protected")
+	cmd.Flags().BoolVar(&s.DryRun, "dry-run", false, "Show changes against live service without deploying (exits with an error if there are changes)")
 }
 
 type defaultlessIntValue struct {
@@ -0,0 +1,552 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	DeployManifestAPIVersion = "cli.knative.dev/v1alpha1"
+	DeployManifestKind       = "Deploy"
+)
+
+// DeployManifest is a file representation of deploy flags.
+// Values provided via flags take precedence over values in the manifest.
+type DeployManifest struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+
+	Service string `yaml:"service"`
+	Image   string `yaml:"image"`
+
+	ServiceAccount string `yaml:"serviceAccount"`
+
+	Env           []DeployManifestEnv          `yaml:"env"`
+	EnvSecrets    []DeployManifestEnvSecret    `yaml:"envSecrets"`
+	EnvConfigMaps []DeployManifestEnvConfigMap `yaml:"envConfigMaps"`
+
+	EnvFiles          []string `yaml:"envFiles"`
+	EnvFromSecrets    []string `yaml:"envFromSecrets"`
+	EnvFromConfigMaps []string `yaml:"envFromConfigMaps"`
+
+	Command []string `yaml:"command"`
+	Args    []string `yaml:"args"`
+
+	ReadinessProbe *DeployManifestProbe `yaml:"readinessProbe"`
+	LivenessProbe  *DeployManifestProbe `yaml:"livenessProbe"`
+
+	Scale                DeployManifestScale `yaml:"scale"`
+	ContainerConcurrency *int                `yaml:"containerConcurrency"`
+
+	Build *DeployManifestBuild `yaml:"build"`
+
+	Tags        []string          `yaml:"tags"`
+	Annotations map[string]string `yaml:"annotations"`
+
+	lines *deployManifestLines
+}
+
+type DeployManifestEnv struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type DeployManifestEnvSecret struct {
+	Name   string `yaml:"name"`
+	Secret string `yaml:"secret"`
+	Key    string `yaml:"key"`
+}
+
+type DeployManifestEnvConfigMap struct {
+	Name      string `yaml:"name"`
+	ConfigMap string `yaml:"configMap"`
+	Key       string `yaml:"key"`
+}
+
+type DeployManifestProbe struct {
+	HTTPPath string   `yaml:"httpPath"`
+	TCP      bool     `yaml:"tcp"`
+	Exec     []string `yaml:"exec"`
+
+	Port *int `yaml:"port"`
+
+	InitialDelay DeployManifestDuration `yaml:"initialDelay"`
+	Period       DeployManifestDuration `yaml:"period"`
+	Timeout      DeployManifestDuration `yaml:"timeout"`
+
+	SuccessThreshold *int `yaml:"successThreshold"`
+	FailureThreshold *int `yaml:"failureThreshold"`
+}
+
+type DeployManifestScale struct {
+	Min *int `yaml:"min"`
+	Max *int `yaml:"max"`
+}
+
+type DeployManifestBuild struct {
+	Directory string `yaml:"directory"`
+
+	GitURL      string `yaml:"gitURL"`
+	GitRevision string `yaml:"gitRevision"`
+
+	ServiceAccount string `yaml:"serviceAccount"`
+
+	Template     string                   `yaml:"template"`
+	TemplateKind string                   `yaml:"templateKind"`
+	TemplateArgs []DeployManifestKeyValue `yaml:"templateArgs"`
+	TemplateEnv  []DeployManifestKeyValue `yaml:"templateEnv"`
+
+	Timeout DeployManifestDuration `yaml:"timeout"`
+}
+
+type DeployManifestKeyValue struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type DeployManifestDuration struct {
+	time.Duration
+}
+
+func (d *DeployManifestDuration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+
+	err := unmarshal(&str)
+	if err != nil {
+		return err
+	}
+
+	dur, err := time.ParseDuration(str)
+	if err != nil {
+		// Type errors are reported together with other errors found in the manifest
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: Expected duration '%s' "+
+			"to be in format '10m' or '1h30m'", deployManifestLine(unmarshal), str)}}
+	}
+
+	d.Duration = dur
+
+	return nil
+}
+
+func NewDeployManifestFromPath(path string) (DeployManifest, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return DeployManifest{}, fmt.Errorf("Reading deploy manifest '%s': %s", path, err)
+	}
+
+	manifest, err := NewDeployManifestFromBytes(bytes)
+	if err != nil {
+		return DeployManifest{}, fmt.Errorf("Parsing deploy manifest '%s': %s", path, err)
+	}
+
+	// Env files and source directory are relative to the manifest location
+	for i, envFile := range manifest.EnvFiles {
+		if !filepath.IsAbs(envFile) {
+			manifest.EnvFiles[i] = filepath.Join(filepath.Dir(path), envFile)
+		}
+	}
+
+	if manifest.Build != nil && len(manifest.Build.Directory) > 0 && !filepath.IsAbs(manifest.Build.Directory) {
+		manifest.Build.Directory = filepath.Join(filepath.Dir(path), manifest.Build.Directory)
+	}
+
+	return manifest, nil
+}
+
+func NewDeployManifestFromBytes(bytes []byte) (DeployManifest, error) {
+	var manifest DeployManifest
+
+	// Strict mode reports unknown fields and mistyped values with line numbers
+	err := yaml.UnmarshalStrict(bytes, &manifest)
+	if err != nil {
+		return DeployManifest{}, err
+	}
+
+	manifest.lines, err = newDeployManifestLines(bytes)
+	if err != nil {
+		return DeployManifest{}, err
+	}
+
+	err = manifest.Validate()
+	if err != nil {
+		return DeployManifest{}, err
+	}
+
+	return manifest, nil
+}
+
+func (m DeployManifest) Validate() error {
+	if m.APIVersion != DeployManifestAPIVersion {
+		return m.errorf("apiVersion", "Expected 'apiVersion' to be '%s' but was '%s'", DeployManifestAPIVersion, m.APIVersion)
+	}
+	if m.Kind != DeployManifestKind {
+		return m.errorf("kind", "Expected 'kind' to be '%s' but was '%s'", DeployManifestKind, m.Kind)
+	}
+
+	for i, env := range m.Env {
+		path := fmt.Sprintf("env[%d]", i)
+		err := m.validateKeyValueName(path, env.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, env := range m.EnvSecrets {
+		path := fmt.Sprintf("envSecrets[%d]", i)
+		if len(env.Name) == 0 || len(env.Secret) == 0 || len(env.Key) == 0 {
+			return m.errorf(path, "Expected '%s' to specify non-empty 'name', 'secret' and 'key'", path)
+		}
+		err := m.validateEnvRef(path, env.Name, "secret", env.Secret)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, env := range m.EnvConfigMaps {
+		path := fmt.Sprintf("envConfigMaps[%d]", i)
+		if len(env.Name) == 0 || len(env.ConfigMap) == 0 || len(env.Key) == 0 {
+			return m.errorf(path, "Expected '%s' to specify non-empty 'name', 'configMap' and 'key'", path)
+		}
+		err := m.validateEnvRef(path, env.Name, "configMap", env.ConfigMap)
+		if err != nil {
+			return err
+		}
+	}
+
+	lists := []struct {
+		Path string
+		Vals []string
+	}{
+		{"envFiles", m.EnvFiles},
+		{"envFromSecrets", m.EnvFromSecrets},
+		{"envFromConfigMaps", m.EnvFromConfigMaps},
+	}
+
+	for _, list := range lists {
+		for i, val := range list.Vals {
+			path := fmt.Sprintf("%s[%d]", list.Path, i)
+			if len(val) == 0 {
+				return m.errorf(path, "Expected '%s' to be non-empty", path)
+			}
+		}
+	}
+
+	probes := []struct {
+		Path  string
+		Probe *DeployManifestProbe
+	}{
+		{"readinessProbe", m.ReadinessProbe},
+		{"livenessProbe", m.LivenessProbe},
+	}
+
+	for _, probe := range probes {
+		if probe.Probe == nil {
+			continue
+		}
+
+		var numHandlers int
+		for _, provided := range []bool{len(probe.Probe.HTTPPath) > 0, probe.Probe.TCP, len(probe.Probe.Exec) > 0} {
+			if provided {
+				numHandlers++
+			}
+		}
+
+		if numHandlers != 1 {
+			return m.errorf(probe.Path, "Expected '%s' to specify exactly one of 'httpPath', 'tcp' or 'exec'", probe.Path)
+		}
+	}
+
+	if m.Scale.Min != nil && *m.Scale.Min < 0 {
+		return m.errorf("scale.min", "Expected 'scale.min' to be non-negative but was '%d'", *m.Scale.Min)
+	}
+	if m.Scale.Max != nil && *m.Scale.Max < 1 {
+		return m.errorf("scale.max", "Expected 'scale.max' to be at least 1 but was '%d'", *m.Scale.Max)
+	}
+	if m.Scale.Min != nil && m.Scale.Max != nil && *m.Scale.Min > *m.Scale.Max {
+		return m.errorf("scale.min", "Expected 'scale.min' to not exceed 'scale.max' but was '%d'", *m.Scale.Min)
+	}
+	if m.ContainerConcurrency != nil && *m.ContainerConcurrency < 0 {
+		return m.errorf("containerConcurrency", "Expected 'containerConcurrency' to be non-negative but was '%d'", *m.ContainerConcurrency)
+	}
+
+	if m.Build != nil {
+		for i, arg := range m.Build.TemplateArgs {
+			err := m.validateKeyValueName(fmt.Sprintf("build.templateArgs[%d]", i), arg.Name)
+			if err != nil {
+				return err
+			}
+		}
+		for i, env := range m.Build.TemplateEnv {
+			err := m.validateKeyValueName(fmt.Sprintf("build.templateEnv[%d]", i), env.Name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateKeyValueName checks names that are later combined into 'name=value' format
+// (same format as used by flags such as '--env')
+func (m DeployManifest) validateKeyValueName(path, name string) error {
+	if len(name) == 0 {
+		return m.errorf(path, "Expected '%s.name' to be non-empty", path)
+	}
+	if strings.Contains(name, "=") {
+		return m.errorf(path+".name", "Expected '%s.name' to not contain '=' but was '%s'", path, name)
+	}
+	return nil
+}
+
+// validateEnvRef checks values that are later combined into 'ENV_KEY=name/key' format
+func (m DeployManifest) validateEnvRef(path, envName, refField, refName string) error {
+	if strings.Contains(envName, "=") {
+		return m.errorf(path+".name", "Expected '%s.name' to not contain '=' but was '%s'", path, envName)
+	}
+	if strings.Contains(refName, "/") {
+		return m.errorf(path+"."+refField, "Expected '%s.%s' to not contain '/' but was '%s'", path, refField, refName)
+	}
+	return nil
+}
+
+// errorf returns an error that includes line of the value at given path;
+// missing values are reported at the line of their closest parent
+func (m DeployManifest) errorf(path string, msg string, args ...interface{}) error {
+	err := fmt.Errorf(msg, args...)
+
+	if line := m.lines.Find(path); line > 0 {
+		return fmt.Errorf("line %d: %s", line, err)
+	}
+
+	return err
+}
+
+// Apply fills in values that were not provided via flags
+func (m DeployManifest) Apply(serviceFlags *cmdflags.ServiceFlags, deployFlags *DeployFlags) {
+	if len(serviceFlags.Name) == 0 {
+		serviceFlags.Name = m.Service
+	}
+
+	if len(deployFlags.Image) == 0 {
+		deployFlags.Image = m.Image
+	}
+	if len(deployFlags.ServiceAccountName) == 0 {
+		deployFlags.ServiceAccountName = m.ServiceAccount
+	}
+
+	m.applyEnv(deployFlags)
+	m.applyContainer(deployFlags)
+
+	if m.ReadinessProbe != nil {
+		m.applyProbe(*m.ReadinessProbe, &deployFlags.ReadinessProbeFlags)
+	}
+	if m.LivenessProbe != nil {
+		m.applyProbe(*m.LivenessProbe, &deployFlags.LivenessProbeFlags)
+	}
+
+	if deployFlags.MinScale == nil {
+		deployFlags.MinScale = m.Scale.Min
+	}
+	if deployFlags.MaxScale == nil {
+		deployFlags.MaxScale = m.Scale.Max
+	}
+	if deployFlags.ContainerConcurrency == nil {
+		deployFlags.ContainerConcurrency = m.ContainerConcurrency
+	}
+
+	if m.Build != nil {
+		m.applyBuild(*m.Build, deployFlags)
+	}
+
+	deployFlags.TagFlags.Tags = m.mergeStrings(m.Tags, deployFlags.TagFlags.Tags)
+
+	var anns []string
+
+	for _, key := range m.sortedKeys(m.Annotations) {
+		anns = append(anns, key+"="+m.Annotations[key])
+	}
+
+	deployFlags.AnnotateFlags.Annotations = m.mergeKeyValues(anns, deployFlags.AnnotateFlags.Annotations)
+}
+
+func (m DeployManifest) applyEnv(deployFlags *DeployFlags) {
+	flagEnvNames := map[string]struct{}{}
+
+	for _, list := range [][]string{deployFlags.EnvVars, deployFlags.EnvSecrets, deployFlags.EnvConfigMaps} {
+		for _, kv := range list {
+			flagEnvNames[strings.SplitN(kv, "=", 2)[0]] = struct{}{}
+		}
+	}
+
+	var envVars, envSecrets, envConfigMaps []string
+
+	for _, env := range m.Env {
+		if _, found := flagEnvNames[env.Name]; !found {
+			envVars = append(envVars, env.Name+"="+env.Value)
+		}
+	}
+
+	for _, env := range m.EnvSecrets {
+		if _, found := flagEnvNames[env.Name]; !found {
+			envSecrets = append(envSecrets, env.Name+"="+env.Secret+"/"+env.Key)
+		}
+	}
+
+	for _, env := range m.EnvConfigMaps {
+		if _, found := flagEnvNames[env.Name]; !found {
+			envConfigMaps = append(envConfigMaps, env.Name+"="+env.ConfigMap+"/"+env.Key)
+		}
+	}
+
+	deployFlags.EnvVars = append(envVars, deployFlags.EnvVars...)
+	deployFlags.EnvSecrets = append(envSecrets, deployFlags.EnvSecrets...)
+	deployFlags.EnvConfigMaps = append(envConfigMaps, deployFlags.EnvConfigMaps...)
+
+	// Env files specified via flags are loaded later hence take precedence
+	deployFlags.EnvFiles = m.mergeStrings(m.EnvFiles, deployFlags.EnvFiles)
+	deployFlags.EnvFromSecrets = m.mergeStrings(m.EnvFromSecrets, deployFlags.EnvFromSecrets)
+	deployFlags.EnvFromConfigMaps = m.mergeStrings(m.EnvFromConfigMaps, deployFlags.EnvFromConfigMaps)
+}
+
+func (m DeployManifest) applyContainer(deployFlags *DeployFlags) {
+	// Command and args are replaced as a whole
+	if len(deployFlags.Command) == 0 {
+		deployFlags.Command = m.Command
+	}
+	if len(deployFlags.Args) == 0 {
+		deployFlags.Args = m.Args
+	}
+}
+
+func (DeployManifest) applyProbe(probe DeployManifestProbe, flags *ProbeFlags) {
+	// Probe handler is specified either fully via flags or fully via manifest
+	if !flags.HasHandler() {
+		flags.HTTPPath = probe.HTTPPath
+		flags.TCP = probe.TCP
+		flags.Exec = probe.Exec
+	}
+
+	if flags.Port == nil {
+		flags.Port = probe.Port
+	}
+
+	if flags.InitialDelay == 0 {
+		flags.InitialDelay = probe.InitialDelay.Duration
+	}
+	if flags.Period == 0 {
+		flags.Period = probe.Period.Duration
+	}
+	if flags.Timeout == 0 {
+		flags.Timeout = probe.Timeout.Duration
+	}
+
+	if flags.SuccessThreshold == nil {
+		flags.SuccessThreshold = probe.SuccessThreshold
+	}
+	if flags.FailureThreshold == nil {
+		flags.FailureThreshold = probe.FailureThreshold
+	}
+}
+
+func (m DeployManifest) applyBuild(build DeployManifestBuild, deployFlags *DeployFlags) {
+	opts := &deployFlags.BuildCreateArgsFlags.BuildSpecOpts
+
+	// Source is specified either fully via flags or fully via manifest
+	if len(opts.SourceDirectory) == 0 && len(opts.GitURL) == 0 {
+		opts.SourceDirectory = build.Directory
+		opts.GitURL = build.GitURL
+		opts.GitRevision = build.GitRevision
+	}
+
+	if len(opts.ServiceAccountName) == 0 {
+		opts.ServiceAccountName = build.ServiceAccount
+	}
+
+	if len(opts.TemplateName) == 0 {
+		opts.TemplateName = build.Template
+	}
+	if len(opts.TemplateKind) == 0 {
+		opts.TemplateKind = build.TemplateKind
+	}
+
+	var args, env []string
+
+	for _, arg := range build.TemplateArgs {
+		args = append(args, arg.Name+"="+arg.Value)
+	}
+	for _, kv := range build.TemplateEnv {
+		env = append(env, kv.Name+"="+kv.Value)
+	}
+
+	opts.TemplateArgs = m.mergeKeyValues(args, opts.TemplateArgs)
+	opts.TemplateEnv = m.mergeKeyValues(env, opts.TemplateEnv)
+
+	if opts.Timeout == 0 {
+		opts.Timeout = build.Timeout.Duration
+	}
+}
+
+func (DeployManifest) mergeStrings(first, second []string) []string {
+	var result []string
+	seen := map[string]struct{}{}
+
+	for _, str := range append(append([]string{}, first...), second...) {
+		if _, found := seen[str]; !found {
+			seen[str] = struct{}{}
+			result = append(result, str)
+		}
+	}
+
+	return result
+}
+
+// mergeKeyValues drops manifest values (format: key=value) for keys that were provided via flags
+func (DeployManifest) mergeKeyValues(manifestKVs, flagKVs []string) []string {
+	var result []string
+	flagKeys := map[string]struct{}{}
+
+	for _, kv := range flagKVs {
+		flagKeys[strings.SplitN(kv, "=", 2)[0]] = struct{}{}
+	}
+
+	for _, kv := range manifestKVs {
+		if _, found := flagKeys[strings.SplitN(kv, "=", 2)[0]]; !found {
+			result = append(result, kv)
+		}
+	}
+
+	return append(result, flagKVs...)
+}
+
+func (DeployManifest) sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
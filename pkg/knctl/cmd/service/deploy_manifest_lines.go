@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	deployManifestPathPieceRegexp = regexp.MustCompile(`^([^\[]*)((?:\[\d+\])*)$`)
+	deployManifestPathIndexRegexp = regexp.MustCompile(`\[(\d+)\]`)
+)
+
+// deployManifestLines records line numbers of manifest values so that
+// semantic errors (not only syntax and type errors) can point to them
+type deployManifestLines struct {
+	Line   int
+	Fields map[string]*deployManifestLines
+	Items  []*deployManifestLines
+}
+
+func newDeployManifestLines(bytes []byte) (*deployManifestLines, error) {
+	var lines deployManifestLines
+
+	err := yaml.Unmarshal(bytes, &lines)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lines, nil
+}
+
+func (l *deployManifestLines) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	l.Line = deployManifestLine(unmarshal)
+
+	var fields map[string]*deployManifestLines
+
+	if unmarshal(&fields) == nil {
+		l.Fields = fields
+		return nil
+	}
+
+	var items []*deployManifestLines
+
+	if unmarshal(&items) == nil {
+		l.Items = items
+	}
+
+	return nil
+}
+
+// Find returns line of the value at given path (e.g. 'build.templateArgs[1].name').
+// Falls back to the line of the closest parent for values that are missing.
+func (l *deployManifestLines) Find(path string) int {
+	if l == nil {
+		return 0
+	}
+
+	line := l.Line
+	curr := l
+
+	for _, piece := range strings.Split(path, ".") {
+		match := deployManifestPathPieceRegexp.FindStringSubmatch(piece)
+		if match == nil {
+			return line
+		}
+
+		curr = curr.Fields[match[1]]
+		if curr == nil {
+			return line
+		}
+		if curr.Line > 0 {
+			line = curr.Line
+		}
+
+		for _, idxMatch := range deployManifestPathIndexRegexp.FindAllStringSubmatch(match[2], -1) {
+			idx, _ := strconv.Atoi(idxMatch[1])
+			if idx >= len(curr.Items) || curr.Items[idx] == nil {
+				return line
+			}
+
+			curr = curr.Items[idx]
+			if curr.Line > 0 {
+				line = curr.Line
+			}
+		}
+	}
+
+	return line
+}
+
+// deployManifestLine returns line of the value that is being unmarshaled.
+// yaml.v2 does not expose node positions, hence line is taken from
+// the type error produced when decoding value into a type that cannot hold it.
+func deployManifestLine(unmarshal func(interface{}) error) int {
+	var impossible func()
+
+	typeErr, ok := unmarshal(&impossible).(*yaml.TypeError)
+	if !ok || len(typeErr.Errors) == 0 {
+		return 0
+	}
+
+	var line int
+
+	_, err := fmt.Sscanf(typeErr.Errors[0], "line %d:", &line)
+	if err != nil {
+		return 0
+	}
+
+	return line
+}
@@ -0,0 +1,390 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	ctlbuild "github.com/cppforlife/knctl/pkg/knctl/build"
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd"
+	cmdbld "github.com/cppforlife/knctl/pkg/knctl/cmd/build"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/service"
+	"gopkg.in/yaml.v2"
+)
+
+const testDeployManifest = `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+
+service: test-service
+image: test-image
+serviceAccount: test-service-account
+
+env:
+- name: key1
+  value: val1
+- name: key2
+  value: 123
+envSecrets:
+- name: key3
+  secret: secret1
+  key: secret-key1
+envConfigMaps:
+- name: key4
+  configMap: config-map1
+  key: config-map-key1
+envFiles: [/tmp/.env]
+envFromSecrets: [secret2]
+envFromConfigMaps: [config-map2]
+
+command: [/app/server]
+args: [--verbose]
+
+readinessProbe:
+  httpPath: /healthz
+  period: 10s
+  failureThreshold: 5
+livenessProbe:
+  tcp: true
+
+scale:
+  min: 1
+  max: 10
+containerConcurrency: 2
+
+build:
+  gitURL: test-git-url
+  gitRevision: test-git-revision
+  serviceAccount: test-build-service-account
+  template: test-template
+  templateArgs:
+  - name: arg1
+    value: arg-val1
+  timeout: 5m
+
+tags: [tag1, tag2]
+annotations:
+  k2: v2
+  k1: v1
+`
+
+func TestDeployManifestApply(t *testing.T) {
+	manifest, err := NewDeployManifestFromBytes([]byte(testDeployManifest))
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	serviceFlags := cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, ""}
+	deployFlags := DeployFlags{}
+
+	manifest.Apply(&serviceFlags, &deployFlags)
+
+	DeepEqual(t, serviceFlags, cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-service"})
+
+	minScale := 1
+	maxScale := 10
+	containerConcurrency := 2
+	failureThreshold := 5
+
+	DeepEqual(t, deployFlags, DeployFlags{
+		BuildCreateArgsFlags: cmdbld.CreateArgsFlags{
+			ctlbuild.BuildSpecOpts{
+				GitURL:             "test-git-url",
+				GitRevision:        "test-git-revision",
+				ServiceAccountName: "test-build-service-account",
+				TemplateName:       "test-template",
+				TemplateArgs:       []string{"arg1=arg-val1"},
+				Timeout:            5 * time.Minute,
+			},
+		},
+		TagFlags:      cmdflags.TagFlags{Tags: []string{"tag1", "tag2"}},
+		AnnotateFlags: cmdflags.AnnotateFlags{Annotations: []string{"k1=v1", "k2=v2"}},
+
+		Image:              "test-image",
+		ServiceAccountName: "test-service-account",
+		EnvVars:            []string{"key1=val1", "key2=123"},
+		EnvSecrets:         []string{"key3=secret1/secret-key1"},
+		EnvConfigMaps:      []string{"key4=config-map1/config-map-key1"},
+
+		EnvFiles:          []string{"/tmp/.env"},
+		EnvFromSecrets:    []string{"secret2"},
+		EnvFromConfigMaps: []string{"config-map2"},
+
+		Command: []string{"/app/server"},
+		Args:    []string{"--verbose"},
+
+		ReadinessProbeFlags: ProbeFlags{
+			HTTPPath:         "/healthz",
+			Period:           10 * time.Second,
+			FailureThreshold: &failureThreshold,
+		},
+		LivenessProbeFlags: ProbeFlags{TCP: true},
+
+		ContainerConcurrency: &containerConcurrency,
+		MinScale:             &minScale,
+		MaxScale:             &maxScale,
+	})
+}
+
+func TestDeployManifestApplyFlagsTakePrecedence(t *testing.T) {
+	manifest, err := NewDeployManifestFromBytes([]byte(testDeployManifest))
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	minScale := 5
+
+	serviceFlags := cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "flag-service"}
+	deployFlags := DeployFlags{
+		BuildCreateArgsFlags: cmdbld.CreateArgsFlags{
+			ctlbuild.BuildSpecOpts{
+				SourceDirectory: "flag-dir",
+				TemplateArgs:    []string{"arg1=flag-arg-val1"},
+			},
+		},
+		TagFlags:      cmdflags.TagFlags{Tags: []string{"tag2", "tag3"}},
+		AnnotateFlags: cmdflags.AnnotateFlags{Annotations: []string{"k1=flag-v1"}},
+
+		Image:              "flag-image",
+		ServiceAccountName: "flag-service-account",
+		EnvVars:            []string{"key2=flag-val2"},
+		EnvSecrets:         []string{"key4=flag-secret/key"},
+
+		EnvFiles:       []string{"flag.env"},
+		EnvFromSecrets: []string{"flag-secret"},
+
+		Args: []string{"--quiet", "--port=80"},
+
+		ReadinessProbeFlags: ProbeFlags{Period: 20 * time.Second},
+		LivenessProbeFlags:  ProbeFlags{Exec: []string{"true"}},
+
+		MinScale: &minScale,
+	}
+
+	manifest.Apply(&serviceFlags, &deployFlags)
+
+	DeepEqual(t, serviceFlags, cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "flag-service"})
+
+	maxScale := 10
+	containerConcurrency := 2
+	failureThreshold := 5
+
+	DeepEqual(t, deployFlags, DeployFlags{
+		BuildCreateArgsFlags: cmdbld.CreateArgsFlags{
+			ctlbuild.BuildSpecOpts{
+				SourceDirectory:    "flag-dir",
+				ServiceAccountName: "test-build-service-account",
+				TemplateName:       "test-template",
+				TemplateArgs:       []string{"arg1=flag-arg-val1"},
+				Timeout:            5 * time.Minute,
+			},
+		},
+		TagFlags:      cmdflags.TagFlags{Tags: []string{"tag1", "tag2", "tag3"}},
+		AnnotateFlags: cmdflags.AnnotateFlags{Annotations: []string{"k2=v2", "k1=flag-v1"}},
+
+		Image:              "flag-image",
+		ServiceAccountName: "flag-service-account",
+		EnvVars:            []string{"key1=val1", "key2=flag-val2"},
+		EnvSecrets:         []string{"key3=secret1/secret-key1", "key4=flag-secret/key"},
+		EnvConfigMaps:      nil,
+
+		EnvFiles:          []string{"/tmp/.env", "flag.env"},
+		EnvFromSecrets:    []string{"secret2", "flag-secret"},
+		EnvFromConfigMaps: []string{"config-map2"},
+
+		Command: []string{"/app/server"},
+		Args:    []string{"--quiet", "--port=80"},
+
+		ReadinessProbeFlags: ProbeFlags{
+			HTTPPath:         "/healthz",
+			Period:           20 * time.Second,
+			FailureThreshold: &failureThreshold,
+		},
+		LivenessProbeFlags: ProbeFlags{Exec: []string{"true"}},
+
+		ContainerConcurrency: &containerConcurrency,
+		MinScale:             &minScale,
+		MaxScale:             &maxScale,
+	})
+}
+
+func TestDeployManifestInvalidFieldsIncludeLineNumbers(t *testing.T) {
+	examples := map[string]string{
+		"unknown field": `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+imagee: test-image
+`,
+		"wrong type": `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+scale:
+  min: many
+`,
+	}
+
+	for desc, example := range examples {
+		_, err := NewDeployManifestFromBytes([]byte(example))
+		if err == nil {
+			t.Fatalf("[%s] Expected error to happen", desc)
+		}
+		if !strings.Contains(err.Error(), "line ") {
+			t.Fatalf("[%s] Expected error to include line number, but was: %s", desc, err)
+		}
+	}
+}
+
+// testYAMLLineProbe records type error the same way deploy manifest gets value lines
+type testYAMLLineProbe struct {
+	typeErr string
+}
+
+func (p *testYAMLLineProbe) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var impossible func()
+
+	// Error has to be inspected right away since yaml.v2 reuses its storage
+	if typeErr, ok := unmarshal(&impossible).(*yaml.TypeError); ok && len(typeErr.Errors) > 0 {
+		p.typeErr = typeErr.Errors[0]
+	}
+
+	return nil
+}
+
+// Manifest line numbers are parsed from yaml.v2 type errors
+// since it does not expose node positions
+func TestDeployManifestYAMLTypeErrorsIncludeLineNumbers(t *testing.T) {
+	var probes struct {
+		Field1 testYAMLLineProbe `yaml:"field1"`
+		Field2 testYAMLLineProbe `yaml:"field2"`
+	}
+
+	err := yaml.Unmarshal([]byte("field1: val1\n\nfield2:\n- val2\n"), &probes)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	for expectedLine, probe := range map[int]testYAMLLineProbe{1: probes.Field1, 4: probes.Field2} {
+		expectedPrefix := fmt.Sprintf("line %d: ", expectedLine)
+		if !strings.HasPrefix(probe.typeErr, expectedPrefix) {
+			t.Fatalf("Expected type error '%s' to start with '%s'", probe.typeErr, expectedPrefix)
+		}
+	}
+}
+
+func TestDeployManifestInvalid(t *testing.T) {
+	examples := map[string]string{
+		`line 2: Expected 'apiVersion' to be 'cli.knative.dev/v1alpha1' but was 'v1'`: `
+apiVersion: v1
+kind: Deploy
+`,
+		`line 2: Expected 'kind' to be 'Deploy' but was ''`: `
+apiVersion: cli.knative.dev/v1alpha1
+`,
+		`line 5: Expected 'envSecrets[0]' to specify non-empty 'name', 'secret' and 'key'`: `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+envSecrets:
+- name: key1
+  secret: secret1
+`,
+		`line 9: Expected 'envSecrets[1].secret' to not contain '/' but was 'secret1/key1'`: `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+envSecrets:
+- name: key1
+  secret: secret1
+  key: key1
+- name: key2
+  secret: secret1/key1
+  key: key1
+`,
+		`line 5: Expected 'envConfigMaps[0].name' to not contain '=' but was 'key1=val'`: `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+envConfigMaps:
+- name: key1=val
+  configMap: config-map1
+  key: key1
+`,
+		`line 5: Expected 'env[0].name' to not contain '=' but was 'key1=val'`: `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+env:
+- name: key1=val
+  value: val1
+`,
+		`line 7: Expected 'build.templateEnv[0].name' to not contain '=' but was 'key1=val'`: `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+build:
+  gitURL: test-git-url
+  templateEnv:
+  - name: key1=val
+    value: val1
+`,
+		"yaml: unmarshal errors:\n  line 6: Expected duration '5 minutes' to be in format '10m' or '1h30m'": `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+build:
+  gitURL: test-git-url
+  timeout: 5 minutes
+`,
+		`line 5: Expected 'livenessProbe' to specify exactly one of 'httpPath', 'tcp' or 'exec'`: `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+livenessProbe:
+  period: 10s
+`,
+		`line 6: Expected 'scale.max' to be at least 1 but was '0'`: `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+scale:
+  min: 0
+  max: 0
+`,
+		`line 5: Expected 'scale.min' to not exceed 'scale.max' but was '5'`: `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+scale:
+  min: 5
+  max: 2
+`,
+		`line 7: Expected 'build.templateArgs[0].name' to be non-empty`: `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+build:
+  gitURL: test-git-url
+  templateArgs:
+  - value: val1
+`,
+	}
+
+	for expectedErr, example := range examples {
+		_, err := NewDeployManifestFromBytes([]byte(example))
+		if err == nil {
+			t.Fatalf("Expected error to happen")
+		}
+		if err.Error() != expectedErr {
+			t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+		}
+	}
+}
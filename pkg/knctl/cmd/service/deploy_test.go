@@ -17,15 +17,24 @@ limitations under the License.
 package service_test
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/cppforlife/go-cli-ui/ui"
 	ctlbuild "github.com/cppforlife/knctl/pkg/knctl/build"
 	. "github.com/cppforlife/knctl/pkg/knctl/cmd"
 	cmdbld "github.com/cppforlife/knctl/pkg/knctl/cmd/build"
 	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
 	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
 	. "github.com/cppforlife/knctl/pkg/knctl/cmd/service"
+	"github.com/cppforlife/knctl/pkg/knctl/fakes"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestNewDeployCmd_Ok(t *testing.T) {
@@ -54,14 +63,14 @@ func TestNewDeployCmd_Ok(t *testing.T) {
 	DeepEqual(t, realCmd.DeployFlags, DeployFlags{
 		BuildCreateArgsFlags: cmdbld.CreateArgsFlags{
 			ctlbuild.BuildSpecOpts{
-				GitURL:             "test-git-url",
-				GitRevision:        "test-git-revision",
-				ServiceAccountName: "test-service-account",
-				Timeout:            1 * time.Second,
+				GitURL:      "test-git-url",
+				GitRevision: "test-git-revision",
+				Timeout:     1 * time.Second,
 			},
 		},
-		Image:   "test-image",
-		EnvVars: []string{"key1=val1", "key2=val2"},
+		ServiceAccountName: "test-service-account",
+		Image:              "test-image",
+		EnvVars:            []string{"key1=val1", "key2=val2"},
 
 		WatchRevisionReady:        true,
 		WatchRevisionReadyTimeout: 5 * time.Minute,
@@ -89,6 +98,7 @@ func TestNewDeployCmd_OkLongFlagNames(t *testing.T) {
 		"--git-url", "test-git-url",
 		"--git-revision", "test-git-revision",
 		"--service-account", "test-service-account",
+		"--build-service-account", "test-build-service-account",
 		"--image", "test-image",
 		"--env", "key1=val1",
 		"--env", "key2=val2",
@@ -114,12 +124,13 @@ func TestNewDeployCmd_OkLongFlagNames(t *testing.T) {
 			ctlbuild.BuildSpecOpts{
 				GitURL:             "test-git-url",
 				GitRevision:        "test-git-revision",
-				ServiceAccountName: "test-service-account",
+				ServiceAccountName: "test-build-service-account",
 				Timeout:            1 * time.Second,
 			},
 		},
-		Image:   "test-image",
-		EnvVars: []string{"key1=val1", "key2=val2"},
+		ServiceAccountName: "test-service-account",
+		Image:              "test-image",
+		EnvVars:            []string{"key1=val1", "key2=val2"},
 
 		ContainerConcurrency: &containerConcurrency,
 		MinScale:             &minScale,
@@ -236,9 +247,201 @@ func TestNewDeployCmd_EnvValueWithCommas(t *testing.T) {
 	})
 }
 
-func TestNewDeployCmd_RequiredFlags(t *testing.T) {
+func TestNewDeployCmd_Manifest(t *testing.T) {
+	realCmd := NewDeployOptions(nil, cmdcore.NewConfigFactoryImpl(), cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewDeployCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.Execute([]string{
+		"--namespace", "test-namespace",
+		"-f", "test-manifest.yml",
+	})
+	cmd.ExpectReachesExecution()
+
+	// Service name and image may come from deploy manifest
+	DeepEqual(t, realCmd.ServiceFlags,
+		cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, ""})
+
+	DeepEqual(t, realCmd.DeployFlags, DeployFlags{
+		ManifestPath:              "test-manifest.yml",
+		WatchRevisionReady:        true,
+		WatchRevisionReadyTimeout: 5 * time.Minute,
+		WatchPodLogs:              true,
+		ManagedRoute:              true,
+	})
+}
+
+func TestDeployOptionsManifestMissingImage(t *testing.T) {
+	manifestFile, err := ioutil.TempFile("", "knctl-deploy-manifest")
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	defer os.Remove(manifestFile.Name())
+
+	manifest := `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+service: test-service
+`
+
+	err = ioutil.WriteFile(manifestFile.Name(), []byte(manifest), 0600)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	realCmd := NewDeployOptions(nil, cmdcore.NewConfigFactoryImpl(), cmdcore.NewDepsFactory())
+	realCmd.DeployFlags.ManifestPath = manifestFile.Name()
+
+	err = realCmd.Run()
+	if err == nil {
+		t.Fatalf("Expected error to happen")
+	}
+
+	expectedErr := "line 2: Expected image to be specified via '--image' flag or deploy manifest"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+}
+
+func TestNewDeployCmd_DryRun(t *testing.T) {
 	realCmd := NewDeployOptions(nil, cmdcore.NewConfigFactoryImpl(), cmdcore.NewDepsFactory())
 	cmd := NewTestCmd(t, NewDeployCmd(realCmd, cmdcore.FlagsFactory{}))
-	cmd.Execute([]string{})
-	cmd.ExpectRequiredFlags([]string{"image", "service"})
+	cmd.Execute([]string{
+		"--namespace", "test-namespace",
+		"--service", "test-service",
+		"--image", "test-image",
+		"--dry-run",
+	})
+	cmd.ExpectReachesExecution()
+
+	DeepEqual(t, realCmd.DeployFlags, DeployFlags{
+		Image:                     "test-image",
+		WatchRevisionReady:        true,
+		WatchRevisionReadyTimeout: 5 * time.Minute,
+		WatchPodLogs:              true,
+		ManagedRoute:              true,
+		DryRun:                    true,
+	})
+}
+
+func TestDeployOptionsDryRunOnlyUsesServingClient(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+
+	servingClient.AddService(v1alpha1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-service",
+			Namespace: "test-namespace",
+		},
+		Spec: v1alpha1.ServiceSpec{
+			RunLatest: &v1alpha1.RunLatestType{
+				Configuration: v1alpha1.ConfigurationSpec{
+					RevisionTemplate: v1alpha1.RevisionTemplateSpec{
+						Spec: v1alpha1.RevisionSpec{Container: corev1.Container{Image: "test-image"}},
+					},
+				},
+			},
+		},
+	})
+
+	// Core client is not provided hence dry run must not look up warnings
+	depsFactory := fakeDepsFactory{servingClient: servingClient}
+
+	realCmd := NewDeployOptions(ui.NewNoopUI(), fakeConfigFactory{}, depsFactory)
+	realCmd.ServiceFlags = cmdflags.ServiceFlags{NamespaceFlags: cmdcore.NamespaceFlags{Name: "test-namespace"}, Name: "test-service"}
+	realCmd.DeployFlags = DeployFlags{
+		Image:        "other-image",
+		ManagedRoute: true,
+		DryRun:       true,
+	}
+
+	err := realCmd.Run()
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	expectedErr := "Expected no changes, but found 1 change(s) (dry run)"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+}
+
+func TestNewDeployCmd_ForceTags(t *testing.T) {
+	realCmd := NewDeployOptions(nil, cmdcore.NewConfigFactoryImpl(), cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewDeployCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.Execute([]string{
+		"--namespace", "test-namespace",
+		"--service", "test-service",
+		"--image", "test-image",
+		"--tag", "prod",
+		"--force-tags",
+	})
+	cmd.ExpectReachesExecution()
+
+	DeepEqual(t, realCmd.DeployFlags, DeployFlags{
+		TagFlags:                  cmdflags.TagFlags{[]string{"prod"}},
+		Image:                     "test-image",
+		WatchRevisionReady:        true,
+		WatchRevisionReadyTimeout: 5 * time.Minute,
+		WatchPodLogs:              true,
+		ManagedRoute:              true,
+		ForceTags:                 true,
+	})
+}
+
+func TestDeployOptionsProtectedTagsAreNotMoved(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+
+	servingClient.AddService(v1alpha1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-service",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{ctlservice.TagProtectionAnnotationKey: "prod"},
+		},
+		Spec: v1alpha1.ServiceSpec{
+			RunLatest: &v1alpha1.RunLatestType{
+				Configuration: v1alpha1.ConfigurationSpec{
+					RevisionTemplate: v1alpha1.RevisionTemplateSpec{
+						Spec: v1alpha1.RevisionSpec{Container: corev1.Container{Image: "test-image"}},
+					},
+				},
+			},
+		},
+	})
+
+	servingClient.AddRevision(v1alpha1.Revision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-service-00001",
+			Namespace: "test-namespace",
+			Labels: map[string]string{
+				serving.ConfigurationLabelKey: "test-service",
+				"tag.cli.knative.dev/latest":  "true",
+				"tag.cli.knative.dev/prod":    "true",
+			},
+		},
+	})
+
+	depsFactory := fakeDepsFactory{servingClient: servingClient, coreClient: newFakeCoreClient()}
+
+	realCmd := NewDeployOptions(ui.NewNoopUI(), fakeConfigFactory{}, depsFactory)
+	realCmd.ServiceFlags = cmdflags.ServiceFlags{NamespaceFlags: cmdcore.NamespaceFlags{Name: "test-namespace"}, Name: "test-service"}
+	realCmd.DeployFlags = DeployFlags{
+		TagFlags:     cmdflags.TagFlags{[]string{"prod"}},
+		Image:        "other-image",
+		ManagedRoute: true,
+	}
+
+	err := realCmd.Run()
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	expectedErr := "Expected tag 'prod' to not be moved from revision 'test-service-00001' " +
+		"since it is protected (use '--force-tags' to move it)"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+
+	image := servingClient.Service("test-namespace", "test-service").Spec.RunLatest.Configuration.RevisionTemplate.Spec.Container.Image
+	if image != "test-image" {
+		t.Fatalf("Expected service to not be updated but its image was '%s'", image)
+	}
 }
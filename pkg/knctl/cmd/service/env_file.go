@@ -0,0 +1,153 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+var (
+	// Same rules as Kubernetes uses for environment variable names
+	envFileKeyRegexp = regexp.MustCompile(`^[-._a-zA-Z][-._a-zA-Z0-9]*$`)
+
+	envFileDoubleQuoteEscapes = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+)
+
+// EnvFile represents environment variables in dotenv format: one KEY=value per line,
+// optional 'export' prefix, '#' comments, single quoted (literal) or double quoted
+// (with \n, \t, \" escapes) values
+type EnvFile struct {
+	Path string
+	Vars []corev1.EnvVar
+}
+
+func NewEnvFileFromPath(path string) (EnvFile, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return EnvFile{}, fmt.Errorf("Reading env file '%s': %s", path, err)
+	}
+
+	envFile, err := NewEnvFileFromBytes(bytes)
+	if err != nil {
+		return EnvFile{}, fmt.Errorf("Parsing env file '%s': %s", path, err)
+	}
+
+	envFile.Path = path
+
+	return envFile, nil
+}
+
+func NewEnvFileFromBytes(bytes []byte) (EnvFile, error) {
+	var envFile EnvFile
+
+	// Later values take precedence over earlier ones with the same name
+	varIdxs := map[string]int{}
+
+	for i, line := range strings.Split(string(bytes), "\n") {
+		line = strings.TrimSpace(line)
+
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		envVar, err := parseEnvFileLine(line)
+		if err != nil {
+			return EnvFile{}, fmt.Errorf("line %d: %s", i+1, err)
+		}
+
+		if idx, found := varIdxs[envVar.Name]; found {
+			envFile.Vars[idx] = envVar
+		} else {
+			varIdxs[envVar.Name] = len(envFile.Vars)
+			envFile.Vars = append(envFile.Vars, envVar)
+		}
+	}
+
+	return envFile, nil
+}
+
+func parseEnvFileLine(line string) (corev1.EnvVar, error) {
+	line = strings.TrimPrefix(line, "export ")
+
+	pieces := strings.SplitN(line, "=", 2)
+	if len(pieces) != 2 {
+		return corev1.EnvVar{}, fmt.Errorf("Expected environment variable to be in format 'KEY=value'")
+	}
+
+	key := strings.TrimSpace(pieces[0])
+	if !envFileKeyRegexp.MatchString(key) {
+		return corev1.EnvVar{}, fmt.Errorf("Expected environment variable name '%s' to consist of alphanumeric characters, '-', '_' or '.'", key)
+	}
+
+	val, err := parseEnvFileValue(strings.TrimSpace(pieces[1]))
+	if err != nil {
+		return corev1.EnvVar{}, err
+	}
+
+	return corev1.EnvVar{Name: key, Value: val}, nil
+}
+
+func parseEnvFileValue(val string) (string, error) {
+	if len(val) == 0 {
+		return "", nil
+	}
+
+	quote := val[0]
+
+	if quote != '"' && quote != '\'' {
+		// Inline comments must be separated by whitespace (e.g. 'val # comment')
+		if idx := strings.Index(val, " #"); idx >= 0 {
+			val = val[:idx]
+		}
+		return strings.TrimSpace(val), nil
+	}
+
+	var endIdx = -1
+
+	for i := 1; i < len(val); i++ {
+		if quote == '"' && val[i] == '\\' {
+			i++ // skip escaped character
+			continue
+		}
+		if val[i] == quote {
+			endIdx = i
+			break
+		}
+	}
+
+	if endIdx < 0 {
+		return "", fmt.Errorf("Expected quoted value to be terminated with %c", quote)
+	}
+
+	rest := strings.TrimSpace(val[endIdx+1:])
+	if len(rest) > 0 && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("Expected quoted value to be followed only by a comment")
+	}
+
+	unquoted := val[1:endIdx]
+
+	if quote == '"' {
+		unquoted = envFileDoubleQuoteEscapes.Replace(unquoted)
+	}
+
+	return unquoted, nil
+}
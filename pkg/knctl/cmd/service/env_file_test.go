@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"testing"
+
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd"
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/service"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNewEnvFileFromBytes(t *testing.T) {
+	envFile, err := NewEnvFileFromBytes([]byte(`
+# comment
+KEY1=val1
+export KEY2 = val2 # inline comment
+KEY3="quoted # not a comment\n\"escaped\" \\n"
+KEY4='single quoted \n' # comment
+KEY5=
+KEY6=a=b
+KEY1=overridden
+`))
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	DeepEqual(t, envFile.Vars, []corev1.EnvVar{
+		{Name: "KEY1", Value: "overridden"},
+		{Name: "KEY2", Value: "val2"},
+		{Name: "KEY3", Value: "quoted # not a comment\n\"escaped\" \\n"},
+		{Name: "KEY4", Value: `single quoted \n`},
+		{Name: "KEY5", Value: ""},
+		{Name: "KEY6", Value: "a=b"},
+	})
+}
+
+func TestNewEnvFileFromBytesInvalid(t *testing.T) {
+	examples := map[string]string{
+		"line 3: Expected environment variable to be in format 'KEY=value'":                                         "\nKEY1=val1\nKEY2\n",
+		"line 1: Expected environment variable name 'KEY 1' to consist of alphanumeric characters, '-', '_' or '.'": "KEY 1=val1",
+		"line 1: Expected quoted value to be terminated with \"":                                                    "KEY1=\"val1",
+		"line 1: Expected quoted value to be followed only by a comment":                                            "KEY1='val1' val2",
+	}
+
+	for expectedErr, example := range examples {
+		_, err := NewEnvFileFromBytes([]byte(example))
+		if err == nil {
+			t.Fatalf("Expected error to happen")
+		}
+
+		if err.Error() != expectedErr {
+			t.Fatalf("Expected error '%s', but was '%s'", expectedErr, err)
+		}
+	}
+}
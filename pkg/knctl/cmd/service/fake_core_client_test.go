@@ -0,0 +1,118 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// fakeCoreClient implements only parts of the clientset used by deploy checks and ingress lookup.
+// Unimplemented methods panic via nil embedded interfaces.
+type fakeCoreClient struct {
+	kubernetes.Interface
+
+	serviceAccounts map[string]corev1.ServiceAccount
+	secrets         map[string]corev1.Secret
+	services        []corev1.Service
+}
+
+func newFakeCoreClient() *fakeCoreClient {
+	return &fakeCoreClient{
+		serviceAccounts: map[string]corev1.ServiceAccount{},
+		secrets:         map[string]corev1.Secret{},
+	}
+}
+
+func (c *fakeCoreClient) CoreV1() typedcorev1.CoreV1Interface {
+	return fakeCoreV1{client: c}
+}
+
+func (c *fakeCoreClient) AddServiceAccount(sa corev1.ServiceAccount) {
+	c.serviceAccounts[sa.Name] = sa
+}
+
+func (c *fakeCoreClient) AddSecret(secret corev1.Secret) {
+	c.secrets[secret.Name] = secret
+}
+
+func (c *fakeCoreClient) AddService(service corev1.Service) {
+	c.services = append(c.services, service)
+}
+
+type fakeCoreV1 struct {
+	typedcorev1.CoreV1Interface
+	client *fakeCoreClient
+}
+
+func (c fakeCoreV1) ServiceAccounts(string) typedcorev1.ServiceAccountInterface {
+	return fakeServiceAccounts{client: c.client}
+}
+
+func (c fakeCoreV1) Secrets(string) typedcorev1.SecretInterface {
+	return fakeSecrets{client: c.client}
+}
+
+func (c fakeCoreV1) Services(namespace string) typedcorev1.ServiceInterface {
+	return fakeServices{client: c.client, namespace: namespace}
+}
+
+type fakeServiceAccounts struct {
+	typedcorev1.ServiceAccountInterface
+	client *fakeCoreClient
+}
+
+func (c fakeServiceAccounts) Get(name string, _ metav1.GetOptions) (*corev1.ServiceAccount, error) {
+	sa, found := c.client.serviceAccounts[name]
+	if !found {
+		return nil, errors.NewNotFound(corev1.Resource("serviceaccounts"), name)
+	}
+	return sa.DeepCopy(), nil
+}
+
+type fakeSecrets struct {
+	typedcorev1.SecretInterface
+	client *fakeCoreClient
+}
+
+func (c fakeSecrets) Get(name string, _ metav1.GetOptions) (*corev1.Secret, error) {
+	secret, found := c.client.secrets[name]
+	if !found {
+		return nil, errors.NewNotFound(corev1.Resource("secrets"), name)
+	}
+	return secret.DeepCopy(), nil
+}
+
+type fakeServices struct {
+	typedcorev1.ServiceInterface
+	client    *fakeCoreClient
+	namespace string
+}
+
+// List ignores label selector
+func (c fakeServices) List(metav1.ListOptions) (*corev1.ServiceList, error) {
+	list := &corev1.ServiceList{}
+	for _, service := range c.client.services {
+		if service.Namespace == c.namespace {
+			list.Items = append(list.Items, *service.DeepCopy())
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	buildclientset "github.com/knative/build/pkg/client/clientset/versioned"
+	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// fakeConfigFactory only provides empty REST config
+type fakeConfigFactory struct {
+	cmdcore.ConfigFactory
+}
+
+func (fakeConfigFactory) RESTConfig() (*rest.Config, error) {
+	return &rest.Config{}, nil
+}
+
+// fakeDepsFactory only provides serving, build and core clients
+// (build client is nil unless set)
+type fakeDepsFactory struct {
+	cmdcore.DepsFactory
+	servingClient servingclientset.Interface
+	buildClient   buildclientset.Interface
+	coreClient    kubernetes.Interface
+}
+
+func (f fakeDepsFactory) ServingClient() (servingclientset.Interface, error) {
+	return f.servingClient, nil
+}
+
+func (f fakeDepsFactory) CoreClient() (kubernetes.Interface, error) {
+	return f.coreClient, nil
+}
+
+func (f fakeDepsFactory) BuildClient() (buildclientset.Interface, error) {
+	return f.buildClient, nil
+}
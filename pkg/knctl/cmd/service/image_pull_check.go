@@ -0,0 +1,164 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultServiceAccountName = "default"
+	dockerHubRegistry         = "index.docker.io"
+
+	// Knative Build uses annotated basic auth secrets to push images
+	buildDockerSecretAnnotationPrefix = "build.knative.dev/docker-"
+)
+
+// ImagePullWarnings returns descriptions of problems that may prevent
+// runtime service account from pulling service image. Registry is considered
+// private when runtime or build service account holds push credentials for it.
+// Checks are best effort, hence API errors other than 'not found' are ignored.
+func (s ServiceSpec) ImagePullWarnings(coreClient kubernetes.Interface) ([]string, error) {
+	runtimeSAName := s.RuntimeServiceAccountName()
+	if len(runtimeSAName) == 0 {
+		runtimeSAName = defaultServiceAccountName
+	}
+
+	runtimeSA, err := coreClient.CoreV1().ServiceAccounts(s.Namespace()).Get(runtimeSAName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []string{fmt.Sprintf("Expected service account '%s' to exist for running revision", runtimeSAName)}, nil
+		}
+		return nil, nil // best effort
+	}
+
+	registry := imageRegistryHost(s.deployFlags.Image)
+
+	for _, ref := range runtimeSA.ImagePullSecrets {
+		secret, err := coreClient.CoreV1().Secrets(s.Namespace()).Get(ref.Name, metav1.GetOptions{})
+		if err != nil {
+			continue // best effort
+		}
+
+		for _, host := range pullSecretRegistryHosts(*secret) {
+			if host == registry {
+				return nil, nil
+			}
+		}
+	}
+
+	pushSAs := []*corev1.ServiceAccount{runtimeSA}
+
+	buildSAName := s.BuildServiceAccountName()
+
+	if len(buildSAName) > 0 && buildSAName != runtimeSAName {
+		buildSA, err := coreClient.CoreV1().ServiceAccounts(s.Namespace()).Get(buildSAName, metav1.GetOptions{})
+		if err == nil {
+			pushSAs = append(pushSAs, buildSA)
+		}
+	}
+
+	for _, sa := range pushSAs {
+		for _, ref := range sa.Secrets {
+			secret, err := coreClient.CoreV1().Secrets(s.Namespace()).Get(ref.Name, metav1.GetOptions{})
+			if err != nil {
+				continue // best effort
+			}
+
+			for key, url := range secret.Annotations {
+				if strings.HasPrefix(key, buildDockerSecretAnnotationPrefix) && registryURLHost(url) == registry {
+					return []string{fmt.Sprintf("Expected service account '%s' to have image pull secret for registry '%s' "+
+						"since service account '%s' has registry credentials (see 'knctl basic-auth-secret create --for-pulling')",
+						runtimeSAName, registry, sa.Name)}, nil
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// pullSecretRegistryHosts returns registry hosts that pull secret provides credentials for
+func pullSecretRegistryHosts(secret corev1.Secret) []string {
+	var auths map[string]interface{}
+
+	switch secret.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		var config struct {
+			Auths map[string]interface{} `json:"auths"`
+		}
+		if json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config) != nil {
+			return nil
+		}
+		auths = config.Auths
+
+	case corev1.SecretTypeDockercfg:
+		if json.Unmarshal(secret.Data[corev1.DockerConfigKey], &auths) != nil {
+			return nil
+		}
+	}
+
+	var hosts []string
+
+	for url := range auths {
+		hosts = append(hosts, registryURLHost(url))
+	}
+
+	return hosts
+}
+
+// imageRegistryHost returns registry host for an image reference
+// following Docker conventions (e.g. 'user/app' is hosted on Docker Hub)
+func imageRegistryHost(image string) string {
+	pieces := strings.SplitN(image, "/", 2)
+
+	if len(pieces) == 1 {
+		return dockerHubRegistry
+	}
+
+	if !strings.ContainsAny(pieces[0], ".:") && pieces[0] != "localhost" {
+		return dockerHubRegistry
+	}
+
+	return normalizeRegistryHost(pieces[0])
+}
+
+// registryURLHost returns registry host for a URL used in credentials
+// (e.g. 'https://index.docker.io/v1/' or 'gcr.io')
+func registryURLHost(url string) string {
+	for _, scheme := range []string{"https://", "http://"} {
+		url = strings.TrimPrefix(url, scheme)
+	}
+
+	return normalizeRegistryHost(strings.SplitN(url, "/", 2)[0])
+}
+
+func normalizeRegistryHost(host string) string {
+	switch host {
+	case "docker.io", "registry-1.docker.io":
+		return dockerHubRegistry
+	default:
+		return host
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"reflect"
+	"testing"
+
+	ctlbuild "github.com/cppforlife/knctl/pkg/knctl/build"
+	cmdbld "github.com/cppforlife/knctl/pkg/knctl/cmd/build"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/service"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newImagePullCheckCoreClient() *fakeCoreClient {
+	coreClient := newFakeCoreClient()
+
+	coreClient.AddSecret(corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "push-secret",
+			Annotations: map[string]string{"build.knative.dev/docker-0": "https://index.docker.io/v1/"},
+		},
+		Type: corev1.SecretTypeBasicAuth,
+	})
+
+	coreClient.AddSecret(corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull-secret"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"https://index.docker.io/v1/":{"auth":"dXNlcjpwYXNz"}}}`),
+		},
+	})
+
+	coreClient.AddServiceAccount(corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "build-sa"},
+		Secrets:    []corev1.ObjectReference{{Name: "push-secret"}},
+	})
+
+	coreClient.AddServiceAccount(corev1.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Name: "runtime-sa"},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "pull-secret"}},
+	})
+
+	coreClient.AddServiceAccount(corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+	})
+
+	return coreClient
+}
+
+func TestServiceSpecImagePullWarnings(t *testing.T) {
+	serviceFlags := cmdflags.ServiceFlags{
+		NamespaceFlags: cmdcore.NamespaceFlags{Name: "test-namespace"},
+		Name:           "test-service",
+	}
+
+	buildFlags := func(sa string) cmdbld.CreateArgsFlags {
+		return cmdbld.CreateArgsFlags{ctlbuild.BuildSpecOpts{GitURL: "test-git-url", ServiceAccountName: sa}}
+	}
+
+	examples := []struct {
+		Desc             string
+		DeployFlags      DeployFlags
+		ExpectedWarnings []string
+	}{
+		{
+			Desc:        "runtime sa with pull secret",
+			DeployFlags: DeployFlags{Image: "docker.io/user/app", ServiceAccountName: "runtime-sa", BuildCreateArgsFlags: buildFlags("build-sa")},
+		},
+		{
+			Desc:        "runtime sa without pull secret for other registry",
+			DeployFlags: DeployFlags{Image: "gcr.io/project/app", ServiceAccountName: "runtime-sa", BuildCreateArgsFlags: buildFlags("build-sa")},
+		},
+		{
+			Desc:        "default sa without build credentials",
+			DeployFlags: DeployFlags{Image: "user/app"},
+		},
+		{
+			Desc:        "default sa with build credentials",
+			DeployFlags: DeployFlags{Image: "user/app", ServiceAccountName: "default", BuildCreateArgsFlags: buildFlags("build-sa")},
+			ExpectedWarnings: []string{
+				"Expected service account 'default' to have image pull secret for registry 'index.docker.io' " +
+					"since service account 'build-sa' has registry credentials (see 'knctl basic-auth-secret create --for-pulling')",
+			},
+		},
+		{
+			Desc:        "same sa for build and runtime without pull secret",
+			DeployFlags: DeployFlags{Image: "index.docker.io/user/app", BuildCreateArgsFlags: buildFlags("build-sa")},
+			ExpectedWarnings: []string{
+				"Expected service account 'build-sa' to have image pull secret for registry 'index.docker.io' " +
+					"since service account 'build-sa' has registry credentials (see 'knctl basic-auth-secret create --for-pulling')",
+			},
+		},
+		{
+			Desc:             "missing runtime sa",
+			DeployFlags:      DeployFlags{Image: "user/app", ServiceAccountName: "missing-sa"},
+			ExpectedWarnings: []string{"Expected service account 'missing-sa' to exist for running revision"},
+		},
+	}
+
+	for _, ex := range examples {
+		warnings, err := NewServiceSpec(serviceFlags, ex.DeployFlags).ImagePullWarnings(newImagePullCheckCoreClient())
+		if err != nil {
+			t.Fatalf("[%s] Expected error to not happen: %s", ex.Desc, err)
+		}
+
+		if !reflect.DeepEqual(warnings, ex.ExpectedWarnings) {
+			t.Fatalf("[%s] Expected warnings '%#v' to equal '%#v'", ex.Desc, warnings, ex.ExpectedWarnings)
+		}
+	}
+}
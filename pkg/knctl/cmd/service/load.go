@@ -0,0 +1,247 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	cmdroute "github.com/cppforlife/knctl/pkg/knctl/cmd/route"
+	ctlload "github.com/cppforlife/knctl/pkg/knctl/load"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type LoadOptions struct {
+	ui          ui.UI
+	depsFactory cmdcore.DepsFactory
+
+	ServiceFlags cmdflags.ServiceFlags
+	CurlFlags    CurlFlags
+	LoadFlags    LoadFlags
+	Route        string
+	Target       string
+}
+
+func NewLoadOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *LoadOptions {
+	return &LoadOptions{ui: ui, depsFactory: depsFactory}
+}
+
+func NewLoadCmd(o *LoadOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "load",
+		Short: "Load test service",
+		Long: `Send HTTP requests at a constant rate to the first ingress address with the Host header set to the service's domain
+(or domain of the route specified via '--route' flag).
+
+Reports latency percentiles, response statuses and number of requests that were slow enough to be considered cold starts.
+
+Either service or route has to be specified.`,
+		Example: `
+  # Send 50 requests per second for 1 minute to service 'svc1' in namespace 'ns1'
+  knctl service load -s svc1 --rps 50 --duration 60s --concurrency 10 -n ns1
+
+  # Load test traffic target named 'candidate' of service 'svc1' in namespace 'ns1'
+  knctl service load -s svc1 --target candidate -n ns1
+
+  # Load test route 'rt1' in namespace 'ns1'
+  knctl service load --route rt1 -n ns1`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	o.ServiceFlags.SetOptional(cmd, flagsFactory)
+	o.CurlFlags.Set(cmd, flagsFactory)
+	o.LoadFlags.Set(cmd, flagsFactory)
+	cmd.Flags().StringVar(&o.Route, "route", "", "Set route to send requests to instead of service's route")
+	cmd.Flags().StringVar(&o.Target, "target", "", "Set traffic target name of service's route (or specified route) to send requests to")
+	return cmd
+}
+
+func (o *LoadOptions) Run() error {
+	if len(o.ServiceFlags.Name) == 0 && len(o.Route) == 0 {
+		return fmt.Errorf("Expected service to be specified via '--service' flag or route via '--route' flag")
+	}
+	if len(o.ServiceFlags.Name) > 0 && len(o.Route) > 0 {
+		return fmt.Errorf("Expected only one of '--service' and '--route' flags to be specified")
+	}
+
+	opts, err := o.LoadFlags.GeneratorOpts()
+	if err != nil {
+		return err
+	}
+
+	domain, url, err := o.addr()
+	if err != nil {
+		return err
+	}
+
+	o.ui.PrintLinef("Sending %d requests per second for %s to '%s'", opts.RPS, opts.Duration, domain)
+
+	result := ctlload.NewGenerator(url, domain, opts).Run()
+
+	o.printSummary(result)
+	o.printLatencies(result)
+	o.printStatusCodes(result)
+
+	return nil
+}
+
+func (o *LoadOptions) addr() (string, string, error) {
+	servingClient, err := o.depsFactory.ServingClient()
+	if err != nil {
+		return "", "", err
+	}
+
+	coreClient, err := o.depsFactory.CoreClient()
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(o.Route) == 0 && len(o.Target) == 0 {
+		service, err := servingClient.ServingV1alpha1().Services(o.ServiceFlags.NamespaceFlags.Name).Get(o.ServiceFlags.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", "", err
+		}
+
+		serviceAddr := ServiceAddress{service, coreClient}
+
+		domain, err := serviceAddr.Domain()
+		if err != nil {
+			return "", "", err
+		}
+
+		url, err := serviceAddr.URL(o.CurlFlags.Port, false)
+		if err != nil {
+			return "", "", err
+		}
+
+		return domain, url, nil
+	}
+
+	routeName := o.Route
+
+	if len(routeName) == 0 {
+		// Assumes that service's route has the same name as the service
+		routeName = o.ServiceFlags.Name
+	}
+
+	route, err := servingClient.ServingV1alpha1().Routes(o.ServiceFlags.NamespaceFlags.Name).Get(routeName, metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("Getting route: %s", err)
+	}
+
+	routeAddr := cmdroute.NewRouteAddress(route, coreClient)
+
+	var domain string
+
+	if len(o.Target) > 0 {
+		domain, err = routeAddr.TargetDomain(o.Target)
+	} else {
+		domain, err = routeAddr.Domain()
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	url, err := routeAddr.URL(o.CurlFlags.Port, false)
+	if err != nil {
+		return "", "", err
+	}
+
+	return domain, url, nil
+}
+
+func (o *LoadOptions) printSummary(result ctlload.Result) {
+	table := uitable.Table{
+		Title:   "Summary",
+		Content: "summary",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Duration"),
+			uitable.NewHeader("Requests"),
+			uitable.NewHeader("Requests per second"),
+			uitable.NewHeader("Errors"),
+			uitable.NewHeader("Cold starts"),
+		},
+
+		Transpose: true,
+	}
+
+	table.Rows = append(table.Rows, []uitable.Value{
+		uitable.NewValueString(result.Duration.Round(time.Millisecond).String()),
+		uitable.NewValueInt(result.Requests),
+		uitable.NewValueString(fmt.Sprintf("%.1f", result.RPS())),
+		uitable.NewValueInt(result.Errors),
+		uitable.NewValueInt(result.ColdStarts),
+	})
+
+	o.ui.PrintTable(table)
+}
+
+func (o *LoadOptions) printLatencies(result ctlload.Result) {
+	table := uitable.Table{
+		Title:   "Latencies",
+		Content: "latencies",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Percentile"),
+			uitable.NewHeader("Latency"),
+		},
+	}
+
+	for _, percent := range []float64{50, 90, 95, 99, 100} {
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueString(fmt.Sprintf("p%v", percent)),
+			uitable.NewValueString(result.Percentile(percent).Round(time.Millisecond).String()),
+		})
+	}
+
+	o.ui.PrintTable(table)
+}
+
+func (o *LoadOptions) printStatusCodes(result ctlload.Result) {
+	table := uitable.Table{
+		Title:   "Statuses",
+		Content: "statuses",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Status"),
+			uitable.NewHeader("Requests"),
+		},
+	}
+
+	var codes []int
+
+	for code := range result.StatusCodes {
+		codes = append(codes, code)
+	}
+
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueInt(code),
+			uitable.NewValueInt(result.StatusCodes[code]),
+		})
+	}
+
+	o.ui.PrintTable(table)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	ctlload "github.com/cppforlife/knctl/pkg/knctl/load"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Requests are sent on ticks every second divided by RPS
+	loadFlagsMaxRPS = 10000
+)
+
+type LoadFlags struct {
+	RPS                int
+	Duration           time.Duration
+	Concurrency        int
+	Timeout            time.Duration
+	ColdStartThreshold time.Duration
+}
+
+func (s *LoadFlags) Set(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory) {
+	cmd.Flags().IntVar(&s.RPS, "rps", 10, "Set number of requests sent per second")
+	cmd.Flags().DurationVar(&s.Duration, "duration", 30*time.Second, "Set duration of the load test")
+	cmd.Flags().IntVar(&s.Concurrency, "concurrency", 10, "Set maximum number of concurrent requests")
+	cmd.Flags().DurationVar(&s.Timeout, "timeout", 30*time.Second, "Set request timeout")
+	cmd.Flags().DurationVar(&s.ColdStartThreshold, "cold-start-threshold", 2*time.Second,
+		"Set latency above which requests are counted as cold starts")
+}
+
+func (s *LoadFlags) GeneratorOpts() (ctlload.GeneratorOpts, error) {
+	if s.RPS <= 0 {
+		return ctlload.GeneratorOpts{}, fmt.Errorf("Expected requests per second to be greater than 0")
+	}
+	if s.RPS > loadFlagsMaxRPS {
+		return ctlload.GeneratorOpts{}, fmt.Errorf("Expected requests per second to be at most %d", loadFlagsMaxRPS)
+	}
+	if s.Concurrency <= 0 {
+		return ctlload.GeneratorOpts{}, fmt.Errorf("Expected concurrency to be greater than 0")
+	}
+	if s.Duration <= 0 {
+		return ctlload.GeneratorOpts{}, fmt.Errorf("Expected duration to be greater than 0")
+	}
+
+	opts := ctlload.GeneratorOpts{
+		RPS:                s.RPS,
+		Duration:           s.Duration,
+		Concurrency:        s.Concurrency,
+		Timeout:            s.Timeout,
+		ColdStartThreshold: s.ColdStartThreshold,
+	}
+
+	return opts, nil
+}
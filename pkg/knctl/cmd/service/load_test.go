@@ -0,0 +1,209 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	. "github.com/cppforlife/knctl/pkg/knctl/cmd/service"
+	"github.com/cppforlife/knctl/pkg/knctl/fakes"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewLoadCmd_Ok(t *testing.T) {
+	realCmd := NewLoadOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewLoadCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.ExpectBasicConfig()
+	cmd.Execute([]string{
+		"-n", "test-namespace",
+		"-s", "test-service",
+		"-p", "1234",
+		"--rps", "50",
+		"--duration", "60s",
+		"--concurrency", "5",
+		"--timeout", "5s",
+		"--cold-start-threshold", "1s",
+		"--route", "test-route",
+		"--target", "candidate",
+	})
+	cmd.ExpectReachesExecution()
+
+	DeepEqual(t, realCmd.ServiceFlags,
+		cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-service"})
+	DeepEqual(t, realCmd.CurlFlags, CurlFlags{Port: int32(1234)})
+	DeepEqual(t, realCmd.LoadFlags, LoadFlags{
+		RPS:                50,
+		Duration:           60 * time.Second,
+		Concurrency:        5,
+		Timeout:            5 * time.Second,
+		ColdStartThreshold: time.Second,
+	})
+	DeepEqual(t, realCmd.Route, "test-route")
+	DeepEqual(t, realCmd.Target, "candidate")
+}
+
+func TestNewLoadCmd_OkMinimum(t *testing.T) {
+	realCmd := NewLoadOptions(nil, cmdcore.NewDepsFactory())
+	cmd := NewTestCmd(t, NewLoadCmd(realCmd, cmdcore.FlagsFactory{}))
+	cmd.Execute([]string{
+		"--namespace", "test-namespace",
+		"--service", "test-service",
+	})
+	cmd.ExpectReachesExecution()
+
+	DeepEqual(t, realCmd.ServiceFlags,
+		cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-service"})
+	DeepEqual(t, realCmd.CurlFlags, CurlFlags{Port: int32(80)})
+	DeepEqual(t, realCmd.LoadFlags, LoadFlags{
+		RPS:                10,
+		Duration:           30 * time.Second,
+		Concurrency:        10,
+		Timeout:            30 * time.Second,
+		ColdStartThreshold: 2 * time.Second,
+	})
+	DeepEqual(t, realCmd.Route, "")
+	DeepEqual(t, realCmd.Target, "")
+}
+
+func TestLoadOptionsServiceOrRoute(t *testing.T) {
+	examples := []struct {
+		Service     string
+		Route       string
+		ExpectedErr string
+	}{
+		{"", "", "Expected service to be specified via '--service' flag or route via '--route' flag"},
+		{"test-service", "test-route", "Expected only one of '--service' and '--route' flags to be specified"},
+	}
+
+	for _, ex := range examples {
+		realCmd := NewLoadOptions(ui.NewNoopUI(), cmdcore.NewDepsFactory())
+		realCmd.ServiceFlags = cmdflags.ServiceFlags{NamespaceFlags: cmdcore.NamespaceFlags{Name: "test-namespace"}, Name: ex.Service}
+		realCmd.Route = ex.Route
+
+		err := realCmd.Run()
+		if err == nil || err.Error() != ex.ExpectedErr {
+			t.Fatalf("Expected error '%s' but was '%v'", ex.ExpectedErr, err)
+		}
+	}
+}
+
+func TestLoadOptionsRoute(t *testing.T) {
+	var hosts atomic.Value
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts.Store(r.Host)
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	serverPort, err := strconv.Atoi(serverURL.Port())
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	coreClient := newFakeCoreClient()
+	coreClient.AddService(corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "istio-ingressgateway", Namespace: "istio-system"},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{{Port: int32(serverPort)}},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: serverURL.Hostname()}},
+			},
+		},
+	})
+
+	servingClient := fakes.NewServingClient()
+	servingClient.AddRoute(v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-route", Namespace: "test-namespace"},
+		Status: v1alpha1.RouteStatus{
+			Domain:  "test-route.test-namespace.example.com",
+			Traffic: []v1alpha1.TrafficTarget{{Name: "candidate", RevisionName: "test-service-00002"}},
+		},
+	})
+
+	examples := []struct {
+		Target       string
+		ExpectedHost string
+	}{
+		{"", "test-route.test-namespace.example.com"},
+		{"candidate", "candidate.test-route.test-namespace.example.com"},
+	}
+
+	for _, ex := range examples {
+		hosts.Store("")
+
+		realCmd := NewLoadOptions(ui.NewNoopUI(), fakeDepsFactory{servingClient: servingClient, coreClient: coreClient})
+		realCmd.ServiceFlags = cmdflags.ServiceFlags{NamespaceFlags: cmdcore.NamespaceFlags{Name: "test-namespace"}}
+		realCmd.CurlFlags = CurlFlags{Port: int32(serverPort)}
+		realCmd.LoadFlags = LoadFlags{RPS: 10, Duration: 200 * time.Millisecond, Concurrency: 1, Timeout: time.Second}
+		realCmd.Route = "test-route"
+		realCmd.Target = ex.Target
+
+		err := realCmd.Run()
+		if err != nil {
+			t.Fatalf("Expected no error: %s", err)
+		}
+
+		if hosts.Load().(string) != ex.ExpectedHost {
+			t.Fatalf("Expected Host header to be '%s' but was '%s'", ex.ExpectedHost, hosts.Load())
+		}
+	}
+}
+
+func TestLoadFlagsGeneratorOpts(t *testing.T) {
+	examples := []struct {
+		Flags       LoadFlags
+		ExpectedErr string
+	}{
+		{LoadFlags{RPS: 1, Duration: time.Second, Concurrency: 1}, ""},
+		{LoadFlags{RPS: 0, Duration: time.Second, Concurrency: 1}, "Expected requests per second to be greater than 0"},
+		{LoadFlags{RPS: 10000, Duration: time.Second, Concurrency: 1}, ""},
+		{LoadFlags{RPS: 10001, Duration: time.Second, Concurrency: 1}, "Expected requests per second to be at most 10000"},
+		{LoadFlags{RPS: 1, Duration: time.Second, Concurrency: 0}, "Expected concurrency to be greater than 0"},
+		{LoadFlags{RPS: 1, Duration: 0, Concurrency: 1}, "Expected duration to be greater than 0"},
+	}
+
+	for _, ex := range examples {
+		_, err := ex.Flags.GeneratorOpts()
+		if len(ex.ExpectedErr) > 0 {
+			if err == nil || err.Error() != ex.ExpectedErr {
+				t.Fatalf("Expected error '%s' but was '%v'", ex.ExpectedErr, err)
+			}
+		} else if err != nil {
+			t.Fatalf("Expected no error: %s", err)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 /*
 Copyright 2018 The Knative Authors
 
-Licensed under the Apache License, Open 2.0 (the "License");
+Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
 You may obtain a copy of the License at
 
@@ -18,25 +18,30 @@ package service
 
 import (
 	"fmt"
-	"os/exec"
+	"net"
+	"time"
 
 	"github.com/cppforlife/go-cli-ui/ui"
 	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
 	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 type OpenOptions struct {
-	ui          ui.UI
-	depsFactory cmdcore.DepsFactory
+	ui            ui.UI
+	depsFactory   cmdcore.DepsFactory
+	cancelSignals cmdcore.CancelSignals
 
 	ServiceFlags cmdflags.ServiceFlags
 	CurlFlags    CurlFlags
+	PrintOnly    bool
 }
 
-func NewOpenOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *OpenOptions {
-	return &OpenOptions{ui: ui, depsFactory: depsFactory}
+func NewOpenOptions(ui ui.UI, depsFactory cmdcore.DepsFactory, cancelSignals cmdcore.CancelSignals) *OpenOptions {
+	return &OpenOptions{ui: ui, depsFactory: depsFactory, cancelSignals: cancelSignals}
 }
 
 func NewOpenCmd(o *OpenOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
@@ -45,55 +50,99 @@ func NewOpenCmd(o *OpenOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Comman
 		Short: "Open web browser pointing at a service domain",
 		Long: `Open web browser pointing at a service domain.
 
-Requires 'open' command installed on the system.`,
+Uses $BROWSER environment variable if set, otherwise 'open' (macOS), 'xdg-open' (Linux) or default browser (Windows).
+
+If service domain does not resolve (e.g. DNS is not configured for the cluster), local proxy that rewrites Host header is started and web browser is pointed at it instead (see 'knctl service proxy').`,
 		Example: `
   # Open web browser pointing at service 'svc1' in namespace 'ns1'
-  knctl service open -s svc1 -n ns1`,
+  knctl service open -s svc1 -n ns1
+
+  # Print URL of service 'svc1' in namespace 'ns1' without opening web browser
+  knctl service open -s svc1 --print-only -n ns1`,
 		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
 	}
 	o.ServiceFlags.Set(cmd, flagsFactory)
 	o.CurlFlags.Set(cmd, flagsFactory)
+	cmd.Flags().BoolVar(&o.PrintOnly, "print-only", false, "Print URL without opening web browser")
 	return cmd
 }
 
 func (o *OpenOptions) Run() error {
-	url, err := o.addr()
+	servingClient, err := o.depsFactory.ServingClient()
 	if err != nil {
 		return err
 	}
 
-	o.ui.PrintLinef("Opening '%s'", url)
-
-	cmd := exec.Command("open", url)
+	service, err := servingClient.ServingV1alpha1().Services(o.ServiceFlags.NamespaceFlags.Name).Get(o.ServiceFlags.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
 
-	err = cmd.Start()
+	coreClient, err := o.depsFactory.CoreClient()
 	if err != nil {
-		return fmt.Errorf("Starting browser: %s", err)
+		return err
 	}
 
-	return nil
-}
+	serviceAddr := ServiceAddress{service, coreClient}
 
-func (o *OpenOptions) addr() (string, error) {
-	servingClient, err := o.depsFactory.ServingClient()
+	domain, err := serviceAddr.Domain()
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	service, err := servingClient.ServingV1alpha1().Services(o.ServiceFlags.NamespaceFlags.Name).Get(o.ServiceFlags.Name, metav1.GetOptions{})
+	url, err := serviceAddr.URL(o.CurlFlags.Port, true)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	coreClient, err := o.depsFactory.CoreClient()
+	_, lookupErr := net.LookupHost(domain)
+
+	if o.PrintOnly {
+		if lookupErr != nil {
+			o.ui.ErrorLinef("Warning: Domain '%s' does not resolve (use 'knctl service proxy' to reach service without DNS)", domain)
+		}
+		o.ui.PrintBlock([]byte(url))
+		return nil
+	}
+
+	if lookupErr != nil {
+		o.ui.PrintLinef("Domain '%s' does not resolve, starting local proxy", domain)
+		return o.openProxy(service, coreClient)
+	}
+
+	o.ui.PrintLinef("Opening '%s'", url)
+
+	return NewBrowser().Open(url)
+}
+
+func (o *OpenOptions) openProxy(service *v1alpha1.Service, coreClient kubernetes.Interface) error {
+	proxy, err := NewServiceProxy(service, coreClient, o.CurlFlags.Port, 30*time.Second, o.ui)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	url, err := ServiceAddress{service, coreClient}.URL(o.CurlFlags.Port, true)
+	listener, err := net.Listen("tcp", "localhost:0")
 	if err != nil {
-		return "", err
+		return fmt.Errorf("Listening on local port: %s", err)
+	}
+
+	url := "http://" + listener.Addr().String()
+
+	o.ui.PrintLinef("Opening '%s' proxied to service '%s' via ingress '%s' (press Ctrl+C to stop)",
+		url, service.Name, proxy.Addr())
+
+	cancelCh := make(chan struct{})
+
+	o.cancelSignals.Watch(func() {
+		close(cancelCh)
+	})
+
+	// Proxy is listening already hence browser can be started before serving
+	err = NewBrowser().Open(url)
+	if err != nil {
+		listener.Close()
+		return err
 	}
 
-	return url, nil
+	return proxy.Serve(listener, cancelCh)
 }
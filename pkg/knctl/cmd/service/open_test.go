@@ -26,23 +26,25 @@ import (
 )
 
 func TestNewOpenCmd_Ok(t *testing.T) {
-	realCmd := NewOpenOptions(nil, cmdcore.NewDepsFactory())
+	realCmd := NewOpenOptions(nil, cmdcore.NewDepsFactory(), cmdcore.CancelSignals{})
 	cmd := NewTestCmd(t, NewOpenCmd(realCmd, cmdcore.FlagsFactory{}))
 	cmd.ExpectBasicConfig()
 	cmd.Execute([]string{
 		"-n", "test-namespace",
 		"-s", "test-service",
 		"-p", "1234",
+		"--print-only",
 	})
 	cmd.ExpectReachesExecution()
 
 	DeepEqual(t, realCmd.ServiceFlags,
 		cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-service"})
 	DeepEqual(t, realCmd.CurlFlags, CurlFlags{Port: int32(1234)})
+	DeepEqual(t, realCmd.PrintOnly, true)
 }
 
 func TestNewOpenCmd_OkLongFlagNames(t *testing.T) {
-	realCmd := NewOpenOptions(nil, cmdcore.NewDepsFactory())
+	realCmd := NewOpenOptions(nil, cmdcore.NewDepsFactory(), cmdcore.CancelSignals{})
 	cmd := NewTestCmd(t, NewOpenCmd(realCmd, cmdcore.FlagsFactory{}))
 	cmd.Execute([]string{
 		"--namespace", "test-namespace",
@@ -57,7 +59,7 @@ func TestNewOpenCmd_OkLongFlagNames(t *testing.T) {
 }
 
 func TestNewOpenCmd_OkMinimum(t *testing.T) {
-	realCmd := NewOpenOptions(nil, cmdcore.NewDepsFactory())
+	realCmd := NewOpenOptions(nil, cmdcore.NewDepsFactory(), cmdcore.CancelSignals{})
 	cmd := NewTestCmd(t, NewOpenCmd(realCmd, cmdcore.FlagsFactory{}))
 	cmd.Execute([]string{
 		"--namespace", "test-namespace",
@@ -68,10 +70,11 @@ func TestNewOpenCmd_OkMinimum(t *testing.T) {
 	DeepEqual(t, realCmd.ServiceFlags,
 		cmdflags.ServiceFlags{cmdcore.NamespaceFlags{"test-namespace"}, "test-service"})
 	DeepEqual(t, realCmd.CurlFlags, CurlFlags{Port: int32(80)})
+	DeepEqual(t, realCmd.PrintOnly, false)
 }
 
 func TestNewOpenCmd_RequiredFlags(t *testing.T) {
-	realCmd := NewOpenOptions(nil, cmdcore.NewDepsFactory())
+	realCmd := NewOpenOptions(nil, cmdcore.NewDepsFactory(), cmdcore.CancelSignals{})
 	cmd := NewTestCmd(t, NewOpenCmd(realCmd, cmdcore.FlagsFactory{}))
 	cmd.Execute([]string{})
 	cmd.ExpectRequiredFlags([]string{"service"})
@@ -0,0 +1,157 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Knative routes traffic (and probes) to this port unless specified otherwise
+	defaultContainerPort = 8080
+)
+
+type ProbeFlags struct {
+	HTTPPath string
+	TCP      bool
+	Exec     []string
+
+	Port *int
+
+	InitialDelay time.Duration
+	Period       time.Duration
+	Timeout      time.Duration
+
+	SuccessThreshold *int
+	FailureThreshold *int
+}
+
+func (s *ProbeFlags) SetWithPrefix(prefix string, cmd *cobra.Command) {
+	cmd.Flags().StringVar(&s.HTTPPath, prefix+"-http-path", "", "Set "+prefix+" probe HTTP GET path (e.g. '/healthz')")
+	cmd.Flags().BoolVar(&s.TCP, prefix+"-tcp", false, "Set "+prefix+" probe to open TCP connection")
+	cmd.Flags().StringArrayVar(&s.Exec, prefix+"-exec", nil, "Set "+prefix+" probe command (can be specified multiple times, one per command element)")
+
+	cmd.Flags().Var(newDefaultlessIntValue(&s.Port), prefix+"-port",
+		"Set "+prefix+" probe port (must match container port since Knative always probes container port)")
+
+	cmd.Flags().DurationVar(&s.InitialDelay, prefix+"-initial-delay", 0, "Set "+prefix+" probe initial delay (e.g. '5s')")
+	cmd.Flags().DurationVar(&s.Period, prefix+"-period", 0, "Set "+prefix+" probe period (e.g. '10s')")
+	cmd.Flags().DurationVar(&s.Timeout, prefix+"-timeout", 0, "Set "+prefix+" probe timeout (e.g. '1s')")
+
+	cmd.Flags().Var(newDefaultlessIntValue(&s.SuccessThreshold), prefix+"-success-threshold",
+		"Set "+prefix+" probe number of consecutive successes to be considered successful")
+	cmd.Flags().Var(newDefaultlessIntValue(&s.FailureThreshold), prefix+"-failure-threshold",
+		"Set "+prefix+" probe number of consecutive failures to be considered failed")
+}
+
+func (s ProbeFlags) HasHandler() bool {
+	return len(s.HTTPPath) > 0 || s.TCP || len(s.Exec) > 0
+}
+
+func (s ProbeFlags) hasSettings() bool {
+	return s.Port != nil || s.InitialDelay != 0 || s.Period != 0 || s.Timeout != 0 ||
+		s.SuccessThreshold != nil || s.FailureThreshold != nil
+}
+
+// Probe returns nil if probe is not configured
+func (s ProbeFlags) Probe(kind string) (*corev1.Probe, error) {
+	if !s.HasHandler() {
+		if s.hasSettings() {
+			return nil, fmt.Errorf("Expected %s probe HTTP path, TCP or exec command to be specified", kind)
+		}
+		return nil, nil
+	}
+
+	var numHandlers int
+	for _, provided := range []bool{len(s.HTTPPath) > 0, s.TCP, len(s.Exec) > 0} {
+		if provided {
+			numHandlers++
+		}
+	}
+
+	if numHandlers > 1 {
+		return nil, fmt.Errorf("Expected only one of %s probe HTTP path, TCP or exec command to be specified", kind)
+	}
+
+	probe := &corev1.Probe{}
+
+	// Port is not set since Knative fills in container port
+	switch {
+	case len(s.HTTPPath) > 0:
+		probe.Handler.HTTPGet = &corev1.HTTPGetAction{Path: s.HTTPPath}
+	case s.TCP:
+		probe.Handler.TCPSocket = &corev1.TCPSocketAction{}
+	default:
+		probe.Handler.Exec = &corev1.ExecAction{Command: s.Exec}
+	}
+
+	if s.Port != nil {
+		if len(s.Exec) > 0 {
+			return nil, fmt.Errorf("Expected %s probe port to not be specified for exec probe", kind)
+		}
+
+		if *s.Port != defaultContainerPort {
+			return nil, fmt.Errorf("Expected %s probe port '%d' to match container port '%d'", kind, *s.Port, defaultContainerPort)
+		}
+	}
+
+	durations := []struct {
+		Name string
+		Val  time.Duration
+		Min  time.Duration
+		Dst  *int32
+	}{
+		{"initial delay", s.InitialDelay, 0, &probe.InitialDelaySeconds},
+		{"period", s.Period, time.Second, &probe.PeriodSeconds},
+		{"timeout", s.Timeout, time.Second, &probe.TimeoutSeconds},
+	}
+
+	for _, dur := range durations {
+		if dur.Val == 0 {
+			continue
+		}
+		if dur.Val < dur.Min || dur.Val%time.Second != 0 {
+			return nil, fmt.Errorf("Expected %s probe %s '%s' to be a positive whole number of seconds", kind, dur.Name, dur.Val)
+		}
+		*dur.Dst = int32(dur.Val / time.Second)
+	}
+
+	thresholds := []struct {
+		Name string
+		Val  *int
+		Dst  *int32
+	}{
+		{"success threshold", s.SuccessThreshold, &probe.SuccessThreshold},
+		{"failure threshold", s.FailureThreshold, &probe.FailureThreshold},
+	}
+
+	for _, threshold := range thresholds {
+		if threshold.Val == nil {
+			continue
+		}
+		if *threshold.Val < 1 {
+			return nil, fmt.Errorf("Expected %s probe %s '%d' to be at least 1", kind, threshold.Name, *threshold.Val)
+		}
+		*threshold.Dst = int32(*threshold.Val)
+	}
+
+	return probe, nil
+}
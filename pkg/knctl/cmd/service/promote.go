@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"github.com/cppforlife/go-cli-ui/ui"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/spf13/cobra"
+)
+
+type PromoteOptions struct {
+	ui          ui.UI
+	depsFactory cmdcore.DepsFactory
+
+	ServiceFlags cmdflags.ServiceFlags
+}
+
+func NewPromoteOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *PromoteOptions {
+	return &PromoteOptions{ui: ui, depsFactory: depsFactory}
+}
+
+func NewPromoteCmd(o *PromoteOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "promote",
+		Short: "Promote candidate revision of service",
+		Long: `Promote candidate revision of service to be current revision and send all traffic to it.
+
+Service must be deployed with '--release' flag.`,
+		Example: `
+  # Promote candidate revision of service 'srv1' in namespace 'ns1'
+  knctl service promote -s srv1 -n ns1`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	o.ServiceFlags.Set(cmd, flagsFactory)
+	return cmd
+}
+
+func (o *PromoteOptions) Run() error {
+	servingClient, err := o.depsFactory.ServingClient()
+	if err != nil {
+		return err
+	}
+
+	release := ctlservice.NewServiceRelease(servingClient, o.ServiceFlags.NamespaceFlags.Name, o.ServiceFlags.Name)
+
+	revisionName, err := release.Promote()
+	if err != nil {
+		return err
+	}
+
+	o.ui.PrintLinef("Promoted revision '%s' to be current revision of service '%s'", revisionName, o.ServiceFlags.Name)
+
+	return nil
+}
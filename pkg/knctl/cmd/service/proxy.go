@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	ctlproxy "github.com/cppforlife/knctl/pkg/knctl/proxy"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type ProxyOptions struct {
+	ui            ui.UI
+	depsFactory   cmdcore.DepsFactory
+	cancelSignals cmdcore.CancelSignals
+
+	ServiceFlags cmdflags.ServiceFlags
+	ProxyFlags   ProxyFlags
+}
+
+func NewProxyOptions(ui ui.UI, depsFactory cmdcore.DepsFactory, cancelSignals cmdcore.CancelSignals) *ProxyOptions {
+	return &ProxyOptions{ui: ui, depsFactory: depsFactory, cancelSignals: cancelSignals}
+}
+
+func NewProxyCmd(o *ProxyOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "proxy",
+		Short: "Proxy local port to service",
+		Long: `Proxy local port to service.
+
+Requests are forwarded to the first ingress address with the Host header set to the service's domain, so that service can be reached without configuring DNS. WebSockets and streaming responses are supported.`,
+		Example: `
+  # Proxy localhost:8080 to service 'svc1' in namespace 'ns1'
+  knctl service proxy -s svc1 --port 8080 -n ns1`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	o.ServiceFlags.Set(cmd, flagsFactory)
+	o.ProxyFlags.Set(cmd, flagsFactory)
+	return cmd
+}
+
+func (o *ProxyOptions) Run() error {
+	servingClient, err := o.depsFactory.ServingClient()
+	if err != nil {
+		return err
+	}
+
+	service, err := servingClient.ServingV1alpha1().Services(o.ServiceFlags.NamespaceFlags.Name).Get(o.ServiceFlags.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	coreClient, err := o.depsFactory.CoreClient()
+	if err != nil {
+		return err
+	}
+
+	proxy, err := NewServiceProxy(service, coreClient, o.ProxyFlags.IngressPort, o.ProxyFlags.ResolveInterval, o.ui)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", o.ProxyFlags.Port))
+	if err != nil {
+		return fmt.Errorf("Listening on port %d: %s", o.ProxyFlags.Port, err)
+	}
+
+	o.ui.PrintLinef("Proxying 'http://%s' to service '%s' via ingress '%s' (press Ctrl+C to stop)",
+		listener.Addr(), service.Name, proxy.Addr())
+
+	cancelCh := make(chan struct{})
+
+	o.cancelSignals.Watch(func() {
+		close(cancelCh)
+	})
+
+	return proxy.Serve(listener, cancelCh)
+}
+
+// NewServiceProxy returns proxy to service's domain with resolved ingress address
+func NewServiceProxy(service *v1alpha1.Service, coreClient kubernetes.Interface,
+	ingressPort int32, resolveInterval time.Duration, ui ui.UI) (*ctlproxy.Proxy, error) {
+
+	serviceAddr := ServiceAddress{service, coreClient}
+
+	domain, err := serviceAddr.Domain()
+	if err != nil {
+		return nil, err
+	}
+
+	addrFunc := func() (string, error) { return serviceAddr.URL(ingressPort, false) }
+	opts := ctlproxy.ProxyOpts{ResolveInterval: resolveInterval}
+
+	proxy := ctlproxy.NewProxy(domain, addrFunc, opts, ui)
+
+	err = proxy.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	return proxy, nil
+}
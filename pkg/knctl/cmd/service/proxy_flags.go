@@ -0,0 +1,36 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"time"
+
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	"github.com/spf13/cobra"
+)
+
+type ProxyFlags struct {
+	Port            int
+	IngressPort     int32
+	ResolveInterval time.Duration
+}
+
+func (s *ProxyFlags) Set(cmd *cobra.Command, flagsFactory cmdcore.FlagsFactory) {
+	cmd.Flags().IntVar(&s.Port, "port", 8080, "Set local port to listen on")
+	cmd.Flags().Int32Var(&s.IngressPort, "ingress-port", 80, "Set ingress port to forward requests to")
+	cmd.Flags().DurationVar(&s.ResolveInterval, "resolve-interval", 30*time.Second, "Set how often ingress address is re-resolved")
+}
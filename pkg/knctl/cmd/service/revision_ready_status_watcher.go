@@ -17,16 +17,60 @@ limitations under the License.
 package service
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/cppforlife/go-cli-ui/ui"
+	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	probeFailureEventReason = "Unhealthy"
+)
+
+var (
+	// Revision does not recover from these conditions becoming false
+	// (e.g. image cannot be pulled or deployment progress deadline is exceeded)
+	revisionFailureConditionTypes = []duckv1alpha1.ConditionType{
+		v1alpha1.RevisionConditionBuildSucceeded,
+		v1alpha1.RevisionConditionResourcesAvailable,
+		v1alpha1.RevisionConditionContainerHealthy,
+	}
 )
 
 type RevisionReadyStatusWatcher struct {
 	revision      *v1alpha1.Revision
 	servingClient servingclientset.Interface
+	coreClient    kubernetes.Interface
+	ui            ui.UI
+
+	stopOnFailure bool
+}
+
+func NewRevisionReadyStatusWatcher(
+	revision *v1alpha1.Revision,
+	servingClient servingclientset.Interface,
+	coreClient kubernetes.Interface,
+	ui ui.UI,
+) RevisionReadyStatusWatcher {
+	return RevisionReadyStatusWatcher{revision: revision, servingClient: servingClient, coreClient: coreClient, ui: ui}
+}
+
+// WithStopOnFailure makes Wait return as soon as one of revision failure conditions
+// becomes false instead of waiting until cancel channel is closed. It should only be used
+// when waiting longer is not useful (e.g. caller is going to roll back or revision has probes
+// that explain failure); otherwise revision gets a chance to recover (e.g. resources become available)
+func (l RevisionReadyStatusWatcher) WithStopOnFailure(stopOnFailure bool) RevisionReadyStatusWatcher {
+	l.stopOnFailure = stopOnFailure
+	return l
 }
 
 func (l RevisionReadyStatusWatcher) IsReady() (bool, error) {
@@ -38,19 +82,29 @@ func (l RevisionReadyStatusWatcher) IsReady() (bool, error) {
 	return rev.Status.IsReady(), nil
 }
 
+// Wait returns true once revision becomes ready, or false if cancel channel is closed
+// before revision becomes ready (or revision has failed, if stopping on failure)
 func (l RevisionReadyStatusWatcher) Wait(cancelCh chan struct{}) (bool, error) {
+	seenProbeFailures := map[string]struct{}{}
+
 	for {
 		// TODO infinite retry
 
-		ready, err := l.IsReady()
+		rev, err := l.servingClient.ServingV1alpha1().Revisions(l.revision.Namespace).Get(l.revision.Name, metav1.GetOptions{})
 		if err != nil {
 			return false, err
 		}
 
-		if ready {
+		if rev.Status.IsReady() {
 			return true, nil
 		}
 
+		if l.stopOnFailure && l.hasFailed(*rev) {
+			return false, nil
+		}
+
+		l.reportProbeFailures(seenProbeFailures)
+
 		select {
 		case <-cancelCh:
 			return false, nil
@@ -59,3 +113,76 @@ func (l RevisionReadyStatusWatcher) Wait(cancelCh chan struct{}) (bool, error) {
 		}
 	}
 }
+
+// FailingConditions returns revision conditions that are not true
+func (l RevisionReadyStatusWatcher) FailingConditions() (duckv1alpha1.Conditions, error) {
+	rev, err := l.servingClient.ServingV1alpha1().Revisions(l.revision.Namespace).Get(l.revision.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var conds duckv1alpha1.Conditions
+
+	for _, cond := range rev.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			conds = append(conds, cond)
+		}
+	}
+
+	return conds, nil
+}
+
+func (l RevisionReadyStatusWatcher) hasFailed(rev v1alpha1.Revision) bool {
+	for _, condType := range revisionFailureConditionTypes {
+		cond := rev.Status.GetCondition(condType)
+		if cond != nil && cond.Status == corev1.ConditionFalse {
+			return true
+		}
+	}
+	return false
+}
+
+// reportProbeFailures prints readiness/liveness probe failures recorded as pod events.
+// Failures are reported on a best effort basis hence errors are ignored.
+func (l RevisionReadyStatusWatcher) reportProbeFailures(seen map[string]struct{}) {
+	podsList, err := l.coreClient.CoreV1().Pods(l.revision.Namespace).List(metav1.ListOptions{
+		LabelSelector: labels.Set{serving.RevisionLabelKey: l.revision.Name}.String(),
+	})
+	if err != nil || len(podsList.Items) == 0 {
+		return
+	}
+
+	podNames := map[string]struct{}{}
+
+	for _, pod := range podsList.Items {
+		podNames[pod.Name] = struct{}{}
+	}
+
+	eventsList, err := l.coreClient.CoreV1().Events(l.revision.Namespace).List(metav1.ListOptions{
+		FieldSelector: fields.Set{
+			"involvedObject.kind": "Pod",
+			"reason":              probeFailureEventReason,
+		}.String(),
+	})
+	if err != nil {
+		return
+	}
+
+	for _, event := range eventsList.Items {
+		if _, found := podNames[event.InvolvedObject.Name]; !found {
+			continue
+		}
+
+		// Repeated failures are aggregated into the same event with increasing count
+		key := fmt.Sprintf("%s/%d", event.UID, event.Count)
+		if _, found := seen[key]; found {
+			continue
+		}
+
+		seen[key] = struct{}{}
+
+		// Message includes probe type (e.g. 'Readiness probe failed: HTTP probe failed with statuscode: 500')
+		l.ui.PrintLinef("Revision '%s' pod '%s': %s (%d times)",
+			l.revision.Name, event.InvolvedObject.Name, event.Message, event.Count)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"github.com/cppforlife/go-cli-ui/ui"
+	cmdcore "github.com/cppforlife/knctl/pkg/knctl/cmd/core"
+	cmdflags "github.com/cppforlife/knctl/pkg/knctl/cmd/flags"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/spf13/cobra"
+)
+
+type RolloutPercentOptions struct {
+	ui          ui.UI
+	depsFactory cmdcore.DepsFactory
+
+	ServiceFlags cmdflags.ServiceFlags
+	Percent      int
+}
+
+func NewRolloutPercentOptions(ui ui.UI, depsFactory cmdcore.DepsFactory) *RolloutPercentOptions {
+	return &RolloutPercentOptions{ui: ui, depsFactory: depsFactory}
+}
+
+func NewRolloutPercentCmd(o *RolloutPercentOptions, flagsFactory cmdcore.FlagsFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollout-percent",
+		Short: "Set percentage of traffic sent to candidate revision of service",
+		Long: `Set percentage of traffic sent to candidate revision of service (remaining traffic is sent to current revision).
+
+Service must be deployed with '--release' flag.`,
+		Example: `
+  # Send 50% of traffic to candidate revision of service 'srv1' in namespace 'ns1'
+  knctl service rollout-percent -s srv1 -p 50 -n ns1
+
+  # Send all traffic back to current revision of service 'srv1' in namespace 'ns1'
+  knctl service rollout-percent -s srv1 -p 0 -n ns1`,
+		RunE: func(_ *cobra.Command, _ []string) error { return o.Run() },
+	}
+	o.ServiceFlags.Set(cmd, flagsFactory)
+	cmd.Flags().IntVarP(&o.Percent, "percentage", "p", 0, "Set percentage of traffic sent to candidate revision (0-99)")
+	cmd.MarkFlagRequired("percentage")
+	return cmd
+}
+
+func (o *RolloutPercentOptions) Run() error {
+	servingClient, err := o.depsFactory.ServingClient()
+	if err != nil {
+		return err
+	}
+
+	release := ctlservice.NewServiceRelease(servingClient, o.ServiceFlags.NamespaceFlags.Name, o.ServiceFlags.Name)
+
+	err = release.SetRolloutPercent(o.Percent)
+	if err != nil {
+		return err
+	}
+
+	o.ui.PrintLinef("Sending %d%% of traffic to candidate revision of service '%s'", o.Percent, o.ServiceFlags.Name)
+
+	return nil
+}
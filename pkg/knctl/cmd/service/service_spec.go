@@ -18,6 +18,7 @@ package service
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -26,17 +27,21 @@ import (
 	buildv1alpha1 "github.com/knative/build/pkg/apis/build/v1alpha1"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	apirand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
 )
 
 type ServiceSpec struct {
 	serviceFlags cmdflags.ServiceFlags
 	deployFlags  DeployFlags
+
+	// Populated via WithReleaseRevisions
+	releaseRevisions []string
 }
 
 func NewServiceSpec(serviceFlags cmdflags.ServiceFlags, deployFlags DeployFlags) ServiceSpec {
-	return ServiceSpec{serviceFlags, deployFlags}
+	return ServiceSpec{serviceFlags: serviceFlags, deployFlags: deployFlags}
 }
 
 func (s ServiceSpec) Namespace() string { return s.serviceFlags.NamespaceFlags.Name }
@@ -50,6 +55,13 @@ func (s ServiceSpec) NeedsConfigurationUpdate() bool {
 	return !s.deployFlags.ManagedRoute
 }
 
+// WithReleaseRevisions returns spec for a service in release mode
+// that routes traffic to given revisions (current revision first)
+func (s ServiceSpec) WithReleaseRevisions(revisionNames []string) ServiceSpec {
+	s.releaseRevisions = revisionNames
+	return s
+}
+
 func (s ServiceSpec) Service() (v1alpha1.Service, error) {
 	service := v1alpha1.Service{
 		ObjectMeta: s.deployFlags.GenerateNameFlags.Apply(metav1.ObjectMeta{
@@ -59,6 +71,10 @@ func (s ServiceSpec) Service() (v1alpha1.Service, error) {
 	}
 
 	if s.NeedsConfigurationUpdate() {
+		if s.deployFlags.Release {
+			return v1alpha1.Service{}, fmt.Errorf("Expected '--release' to not be used with '--managed-route=false'")
+		}
+
 		service.Spec.Manual = &v1alpha1.ManualType{}
 	} else {
 		conf, err := s.Configuration()
@@ -66,8 +82,16 @@ func (s ServiceSpec) Service() (v1alpha1.Service, error) {
 			return v1alpha1.Service{}, err
 		}
 
-		service.Spec.RunLatest = &v1alpha1.RunLatestType{
-			Configuration: conf.Spec,
+		if s.deployFlags.Release {
+			// Candidate revision is added once it's created
+			service.Spec.Release = &v1alpha1.ReleaseType{
+				Revisions:     s.releaseRevisions,
+				Configuration: conf.Spec,
+			}
+		} else {
+			service.Spec.RunLatest = &v1alpha1.RunLatestType{
+				Configuration: conf.Spec,
+			}
 		}
 	}
 
@@ -80,6 +104,7 @@ func (s ServiceSpec) Configuration() (v1alpha1.Configuration, error) {
 	if s.deployFlags.BuildCreateArgsFlags.IsProvided() {
 		// TODO assumes that same image is used for building and running
 		s.deployFlags.BuildCreateArgsFlags.Image = s.deployFlags.Image
+		s.deployFlags.BuildCreateArgsFlags.ServiceAccountName = s.BuildServiceAccountName()
 
 		spec, err := ctlbuild.BuildSpec{}.Build(s.deployFlags.BuildCreateArgsFlags.BuildSpecOpts)
 		if err != nil {
@@ -90,39 +115,35 @@ func (s ServiceSpec) Configuration() (v1alpha1.Configuration, error) {
 	}
 
 	serviceCont := corev1.Container{
-		Image: s.deployFlags.Image,
+		Image:   s.deployFlags.Image,
+		Command: s.deployFlags.Command,
+		Args:    s.deployFlags.Args,
 	}
 
-	for _, kv := range s.deployFlags.EnvVars {
-		pieces := strings.SplitN(kv, "=", 2)
-		if len(pieces) != 2 {
-			return v1alpha1.Configuration{}, fmt.Errorf("Expected environment variable to be in format 'ENV_KEY=value'")
-		}
-		serviceCont.Env = append(serviceCont.Env, corev1.EnvVar{Name: pieces[0], Value: pieces[1]})
-	}
+	var err error
 
-	envVars, err := s.buildEnvFromSecrets(s.deployFlags)
+	serviceCont.ReadinessProbe, err = s.deployFlags.ReadinessProbeFlags.Probe("readiness")
 	if err != nil {
 		return v1alpha1.Configuration{}, err
 	}
 
-	serviceCont.Env = append(serviceCont.Env, envVars...)
-
-	envVars, err = s.buildEnvFromConfigMaps(s.deployFlags)
+	serviceCont.LivenessProbe, err = s.deployFlags.LivenessProbeFlags.Probe("liveness")
 	if err != nil {
 		return v1alpha1.Configuration{}, err
 	}
 
-	serviceCont.Env = append(serviceCont.Env, envVars...)
+	if serviceCont.LivenessProbe != nil && serviceCont.LivenessProbe.SuccessThreshold > 1 {
+		return v1alpha1.Configuration{}, fmt.Errorf("Expected liveness probe success threshold to be 1")
+	}
 
-	// TODO it's convenient to force redeploy anytime deploy is issued
-	if !s.deployFlags.RemoveKnctlDeployEnvVar {
-		serviceCont.Env = append(serviceCont.Env, corev1.EnvVar{
-			Name:  "KNCTL_DEPLOY",
-			Value: apirand.String(10),
-		})
+	env, _, err := s.buildEnv()
+	if err != nil {
+		return v1alpha1.Configuration{}, err
 	}
 
+	serviceCont.Env = env
+	serviceCont.EnvFrom = s.buildEnvFrom()
+
 	revisionAnns := map[string]string{}
 
 	if s.deployFlags.MinScale != nil {
@@ -141,8 +162,7 @@ func (s ServiceSpec) Configuration() (v1alpha1.Configuration, error) {
 					Annotations: revisionAnns,
 				},
 				Spec: v1alpha1.RevisionSpec{
-					// TODO service account may be different for runtime vs build
-					ServiceAccountName: s.deployFlags.BuildCreateArgsFlags.ServiceAccountName,
+					ServiceAccountName: s.RuntimeServiceAccountName(),
 					Container:          serviceCont,
 				},
 			},
@@ -156,6 +176,198 @@ func (s ServiceSpec) Configuration() (v1alpha1.Configuration, error) {
 	return conf, nil
 }
 
+// RuntimeServiceAccountName returns service account used by revision pods
+// (including for pulling image). Falls back to build service account
+// if runtime service account is not specified.
+func (s ServiceSpec) RuntimeServiceAccountName() string {
+	if len(s.deployFlags.ServiceAccountName) > 0 {
+		return s.deployFlags.ServiceAccountName
+	}
+	return s.deployFlags.BuildCreateArgsFlags.ServiceAccountName
+}
+
+// BuildServiceAccountName returns service account used for building.
+// Falls back to runtime service account if build service account is not specified.
+func (s ServiceSpec) BuildServiceAccountName() string {
+	if len(s.deployFlags.BuildCreateArgsFlags.ServiceAccountName) > 0 {
+		return s.deployFlags.BuildCreateArgsFlags.ServiceAccountName
+	}
+	return s.deployFlags.ServiceAccountName
+}
+
+// EnvWarnings returns descriptions of environment variables that are provided
+// by multiple sources. See buildEnv for precedence rules.
+func (s ServiceSpec) EnvWarnings(coreClient kubernetes.Interface) ([]string, error) {
+	env, warnings, err := s.buildEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	envNames := map[string]struct{}{}
+
+	for _, envVar := range env {
+		envNames[envVar.Name] = struct{}{}
+	}
+
+	// Key name to description of env from source that provides it
+	envFromKeys := map[string]string{}
+
+	for _, envFrom := range s.buildEnvFrom() {
+		var desc string
+		var keys []string
+
+		switch {
+		case envFrom.ConfigMapRef != nil:
+			desc = fmt.Sprintf("config map '%s'", envFrom.ConfigMapRef.Name)
+
+			configMap, err := coreClient.CoreV1().ConfigMaps(s.Namespace()).Get(envFrom.ConfigMapRef.Name, metav1.GetOptions{})
+			if err != nil {
+				if errors.IsNotFound(err) {
+					warnings = append(warnings, fmt.Sprintf("Expected %s to exist for environment variables", desc))
+				}
+				continue // best effort
+			}
+
+			for key := range configMap.Data {
+				keys = append(keys, key)
+			}
+
+		case envFrom.SecretRef != nil:
+			desc = fmt.Sprintf("secret '%s'", envFrom.SecretRef.Name)
+
+			secret, err := coreClient.CoreV1().Secrets(s.Namespace()).Get(envFrom.SecretRef.Name, metav1.GetOptions{})
+			if err != nil {
+				if errors.IsNotFound(err) {
+					warnings = append(warnings, fmt.Sprintf("Expected %s to exist for environment variables", desc))
+				}
+				continue // best effort
+			}
+
+			for key := range secret.Data {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if _, found := envNames[key]; found {
+				warnings = append(warnings, fmt.Sprintf(
+					"Environment variable '%s' from flags or env files overrides value from %s", key, desc))
+				continue
+			}
+
+			if prevDesc, found := envFromKeys[key]; found {
+				warnings = append(warnings, fmt.Sprintf(
+					"Environment variable '%s' from %s overrides value from %s", key, desc, prevDesc))
+			}
+
+			envFromKeys[key] = desc
+		}
+	}
+
+	return warnings, nil
+}
+
+// buildEnv returns environment variables and warnings about overridden values.
+// Precedence (highest first):
+//   - explicitly specified variables (--env, --env-secret, --env-config-map)
+//   - env files (later files take precedence over earlier ones)
+//   - env from config maps and secrets (resolved by Kubernetes; later sources take precedence)
+func (s ServiceSpec) buildEnv() ([]corev1.EnvVar, []string, error) {
+	var explicitEnv []corev1.EnvVar
+
+	for _, kv := range s.deployFlags.EnvVars {
+		pieces := strings.SplitN(kv, "=", 2)
+		if len(pieces) != 2 {
+			return nil, nil, fmt.Errorf("Expected environment variable to be in format 'ENV_KEY=value'")
+		}
+		explicitEnv = append(explicitEnv, corev1.EnvVar{Name: pieces[0], Value: pieces[1]})
+	}
+
+	envVars, err := s.buildEnvFromSecrets(s.deployFlags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	explicitEnv = append(explicitEnv, envVars...)
+
+	envVars, err = s.buildEnvFromConfigMaps(s.deployFlags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	explicitEnv = append(explicitEnv, envVars...)
+
+	var fileEnv []corev1.EnvVar
+	var warnings []string
+
+	fileEnvPaths := map[string]string{}
+	fileEnvIdxs := map[string]int{}
+
+	for _, path := range s.deployFlags.EnvFiles {
+		envFile, err := NewEnvFileFromPath(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, envVar := range envFile.Vars {
+			if prevPath, found := fileEnvPaths[envVar.Name]; found {
+				warnings = append(warnings, fmt.Sprintf(
+					"Environment variable '%s' from env file '%s' overrides value from env file '%s'", envVar.Name, path, prevPath))
+				fileEnv[fileEnvIdxs[envVar.Name]] = envVar
+			} else {
+				fileEnvIdxs[envVar.Name] = len(fileEnv)
+				fileEnv = append(fileEnv, envVar)
+			}
+
+			fileEnvPaths[envVar.Name] = path
+		}
+	}
+
+	explicitNames := map[string]struct{}{}
+
+	for _, envVar := range explicitEnv {
+		explicitNames[envVar.Name] = struct{}{}
+	}
+
+	var result []corev1.EnvVar
+
+	for _, envVar := range fileEnv {
+		if _, found := explicitNames[envVar.Name]; found {
+			warnings = append(warnings, fmt.Sprintf(
+				"Environment variable '%s' from flags overrides value from env file '%s'", envVar.Name, fileEnvPaths[envVar.Name]))
+			continue
+		}
+		result = append(result, envVar)
+	}
+
+	return append(result, explicitEnv...), warnings, nil
+}
+
+func (s ServiceSpec) buildEnvFrom() []corev1.EnvFromSource {
+	var result []corev1.EnvFromSource
+
+	// Kubernetes gives precedence to later sources hence secrets override config maps
+	for _, name := range s.deployFlags.EnvFromConfigMaps {
+		result = append(result, corev1.EnvFromSource{
+			ConfigMapRef: &corev1.ConfigMapEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			},
+		})
+	}
+
+	for _, name := range s.deployFlags.EnvFromSecrets {
+		result = append(result, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			},
+		})
+	}
+
+	return result
+}
+
 func (s ServiceSpec) buildEnvFromSecrets(deployFlags DeployFlags) ([]corev1.EnvVar, error) {
 	var result []corev1.EnvVar
 
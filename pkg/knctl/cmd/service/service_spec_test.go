@@ -17,8 +17,11 @@ limitations under the License.
 package service_test
 
 import (
+	"io/ioutil"
+	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	ctlbuild "github.com/cppforlife/knctl/pkg/knctl/build"
 	cmdbld "github.com/cppforlife/knctl/pkg/knctl/cmd/build"
@@ -51,8 +54,6 @@ func TestServiceSpecWithConcurrency(t *testing.T) {
 		ContainerConcurrency: &containerConcurrency,
 		MinScale:             &minScale,
 		MaxScale:             &maxScale,
-
-		RemoveKnctlDeployEnvVar: true,
 	}
 
 	spec, err := NewServiceSpec(serviceFlags, deployFlags).Service()
@@ -113,8 +114,6 @@ func TestServiceSpecWithBuildConfiguration(t *testing.T) {
 		Image:        "test-image",
 		EnvVars:      []string{"test-env-key1=test-env-val1"},
 		ManagedRoute: true,
-
-		RemoveKnctlDeployEnvVar: true,
 	}
 
 	spec, err := NewServiceSpec(serviceFlags, deployFlags).Service()
@@ -173,6 +172,55 @@ func TestServiceSpecWithBuildConfiguration(t *testing.T) {
 	}
 }
 
+func TestServiceSpecWithSeparateServiceAccounts(t *testing.T) {
+	serviceFlags := cmdflags.ServiceFlags{
+		NamespaceFlags: cmdcore.NamespaceFlags{
+			Name: "test-namespace",
+		},
+		Name: "test-service",
+	}
+
+	examples := []struct {
+		RuntimeSA         string
+		BuildSA           string
+		ExpectedRuntimeSA string
+		ExpectedBuildSA   string
+	}{
+		{"runtime-sa", "build-sa", "runtime-sa", "build-sa"},
+		{"runtime-sa", "", "runtime-sa", "runtime-sa"},
+		{"", "build-sa", "build-sa", "build-sa"},
+		{"", "", "", ""},
+	}
+
+	for _, ex := range examples {
+		deployFlags := DeployFlags{
+			BuildCreateArgsFlags: cmdbld.CreateArgsFlags{
+				ctlbuild.BuildSpecOpts{
+					GitURL:             "test-git-url",
+					ServiceAccountName: ex.BuildSA,
+				},
+			},
+			Image:              "test-image",
+			ServiceAccountName: ex.RuntimeSA,
+		}
+
+		conf, err := NewServiceSpec(serviceFlags, deployFlags).Configuration()
+		if err != nil {
+			t.Fatalf("Expected error to not happen: %s", err)
+		}
+
+		if conf.Spec.RevisionTemplate.Spec.ServiceAccountName != ex.ExpectedRuntimeSA {
+			t.Fatalf("Expected runtime service account to be '%s' but was '%s'",
+				ex.ExpectedRuntimeSA, conf.Spec.RevisionTemplate.Spec.ServiceAccountName)
+		}
+
+		if conf.Spec.Build.BuildSpec.ServiceAccountName != ex.ExpectedBuildSA {
+			t.Fatalf("Expected build service account to be '%s' but was '%s'",
+				ex.ExpectedBuildSA, conf.Spec.Build.BuildSpec.ServiceAccountName)
+		}
+	}
+}
+
 func TestServiceSpecWithoutBuildConfiguration(t *testing.T) {
 	serviceFlags := cmdflags.ServiceFlags{
 		NamespaceFlags: cmdcore.NamespaceFlags{
@@ -185,8 +233,6 @@ func TestServiceSpecWithoutBuildConfiguration(t *testing.T) {
 		Image:        "test-image",
 		EnvVars:      []string{"test-env-key1=test-env-val1"},
 		ManagedRoute: true,
-
-		RemoveKnctlDeployEnvVar: true,
 	}
 
 	spec, err := NewServiceSpec(serviceFlags, deployFlags).Service()
@@ -236,8 +282,6 @@ func TestServiceSpecWithInvalidEnv(t *testing.T) {
 		Image:        "test-image",
 		EnvVars:      []string{"test-env-key1"},
 		ManagedRoute: true,
-
-		RemoveKnctlDeployEnvVar: true,
 	}
 
 	_, err := NewServiceSpec(serviceFlags, deployFlags).Service()
@@ -264,8 +308,6 @@ func TestServiceSpecWithMultipleEnv(t *testing.T) {
 		EnvSecrets:    []string{"test-env-key3=test-secret1/key", "test-env-key4=test-secret2/key"},
 		EnvConfigMaps: []string{"test-env-key5=test-config-map1/key", "test-env-key6=test-config-map2/key"},
 		ManagedRoute:  true,
-
-		RemoveKnctlDeployEnvVar: true,
 	}
 
 	spec, err := NewServiceSpec(serviceFlags, deployFlags).Service()
@@ -350,8 +392,6 @@ func TestServiceSpecWithInvalidEnvSecret(t *testing.T) {
 		Image:        "test-image",
 		EnvSecrets:   []string{"test-env-secret-key1"},
 		ManagedRoute: true,
-
-		RemoveKnctlDeployEnvVar: true,
 	}
 
 	_, err := NewServiceSpec(serviceFlags, deployFlags).Service()
@@ -376,8 +416,6 @@ func TestServiceSpecWithInvalidEnvConfigMap(t *testing.T) {
 		Image:         "test-image",
 		EnvConfigMaps: []string{"test-env-config-map-key1"},
 		ManagedRoute:  true,
-
-		RemoveKnctlDeployEnvVar: true,
 	}
 
 	_, err := NewServiceSpec(serviceFlags, deployFlags).Service()
@@ -389,3 +427,273 @@ func TestServiceSpecWithInvalidEnvConfigMap(t *testing.T) {
 		t.Fatalf("Expected error to happen, but was '%s'", err)
 	}
 }
+
+func TestServiceSpecWithContainerOverrides(t *testing.T) {
+	serviceFlags := cmdflags.ServiceFlags{
+		NamespaceFlags: cmdcore.NamespaceFlags{
+			Name: "test-namespace",
+		},
+		Name: "test-service",
+	}
+
+	deployFlags := DeployFlags{
+		Image:        "test-image",
+		ManagedRoute: true,
+
+		Command: []string{"/app/server", "serve"},
+		Args:    []string{"--verbose"},
+	}
+
+	conf, err := NewServiceSpec(serviceFlags, deployFlags).Configuration()
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	expectedContainer := corev1.Container{
+		Image:   "test-image",
+		Command: []string{"/app/server", "serve"},
+		Args:    []string{"--verbose"},
+	}
+
+	if !reflect.DeepEqual(conf.Spec.RevisionTemplate.Spec.Container, expectedContainer) {
+		t.Fatalf("Expected container '%#v' to equal '%#v'", conf.Spec.RevisionTemplate.Spec.Container, expectedContainer)
+	}
+}
+
+func TestServiceSpecWithProbes(t *testing.T) {
+	serviceFlags := cmdflags.ServiceFlags{
+		NamespaceFlags: cmdcore.NamespaceFlags{
+			Name: "test-namespace",
+		},
+		Name: "test-service",
+	}
+
+	port := 8080
+	failureThreshold := 5
+
+	deployFlags := DeployFlags{
+		Image:        "test-image",
+		ManagedRoute: true,
+
+		ReadinessProbeFlags: ProbeFlags{
+			HTTPPath:         "/healthz",
+			Port:             &port,
+			InitialDelay:     5 * time.Second,
+			Period:           10 * time.Second,
+			FailureThreshold: &failureThreshold,
+		},
+		LivenessProbeFlags: ProbeFlags{
+			Exec:    []string{"cat", "/tmp/healthy"},
+			Timeout: 2 * time.Second,
+		},
+	}
+
+	conf, err := NewServiceSpec(serviceFlags, deployFlags).Configuration()
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	expectedReadinessProbe := &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/healthz"},
+		},
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       10,
+		FailureThreshold:    5,
+	}
+
+	expectedLivenessProbe := &corev1.Probe{
+		Handler: corev1.Handler{
+			Exec: &corev1.ExecAction{Command: []string{"cat", "/tmp/healthy"}},
+		},
+		TimeoutSeconds: 2,
+	}
+
+	cont := conf.Spec.RevisionTemplate.Spec.Container
+
+	if !reflect.DeepEqual(cont.ReadinessProbe, expectedReadinessProbe) {
+		t.Fatalf("Expected readiness probe '%#v' to equal '%#v'", cont.ReadinessProbe, expectedReadinessProbe)
+	}
+
+	if !reflect.DeepEqual(cont.LivenessProbe, expectedLivenessProbe) {
+		t.Fatalf("Expected liveness probe '%#v' to equal '%#v'", cont.LivenessProbe, expectedLivenessProbe)
+	}
+}
+
+func TestServiceSpecWithInvalidProbes(t *testing.T) {
+	serviceFlags := cmdflags.ServiceFlags{
+		NamespaceFlags: cmdcore.NamespaceFlags{
+			Name: "test-namespace",
+		},
+		Name: "test-service",
+	}
+
+	port := 9000
+	threshold := 0
+	successThreshold := 2
+
+	examples := map[string]DeployFlags{
+		"Expected readiness probe HTTP path, TCP or exec command to be specified": DeployFlags{
+			ReadinessProbeFlags: ProbeFlags{Period: time.Second},
+		},
+		"Expected only one of liveness probe HTTP path, TCP or exec command to be specified": DeployFlags{
+			LivenessProbeFlags: ProbeFlags{HTTPPath: "/healthz", TCP: true},
+		},
+		"Expected readiness probe port '9000' to match container port '8080'": DeployFlags{
+			ReadinessProbeFlags: ProbeFlags{TCP: true, Port: &port},
+		},
+		"Expected readiness probe period '1.5s' to be a positive whole number of seconds": DeployFlags{
+			ReadinessProbeFlags: ProbeFlags{TCP: true, Period: 1500 * time.Millisecond},
+		},
+		"Expected readiness probe failure threshold '0' to be at least 1": DeployFlags{
+			ReadinessProbeFlags: ProbeFlags{TCP: true, FailureThreshold: &threshold},
+		},
+		"Expected liveness probe success threshold to be 1": DeployFlags{
+			LivenessProbeFlags: ProbeFlags{TCP: true, SuccessThreshold: &successThreshold},
+		},
+	}
+
+	for expectedErr, deployFlags := range examples {
+		deployFlags.Image = "test-image"
+
+		_, err := NewServiceSpec(serviceFlags, deployFlags).Configuration()
+		if err == nil {
+			t.Fatalf("Expected error to happen")
+		}
+
+		if err.Error() != expectedErr {
+			t.Fatalf("Expected error '%s', but was '%s'", expectedErr, err)
+		}
+	}
+}
+
+func TestServiceSpecWithEnvFilesAndEnvFrom(t *testing.T) {
+	serviceFlags := cmdflags.ServiceFlags{
+		NamespaceFlags: cmdcore.NamespaceFlags{
+			Name: "test-namespace",
+		},
+		Name: "test-service",
+	}
+
+	envFile1 := writeTempFile(t, "KEY1=file1-val1\nKEY2=file1-val2\nKEY3=file1-val3\n")
+	defer os.Remove(envFile1)
+
+	envFile2 := writeTempFile(t, "KEY2=file2-val2\n")
+	defer os.Remove(envFile2)
+
+	deployFlags := DeployFlags{
+		Image:             "test-image",
+		EnvVars:           []string{"KEY3=flag-val3"},
+		EnvFiles:          []string{envFile1, envFile2},
+		EnvFromSecrets:    []string{"secret1"},
+		EnvFromConfigMaps: []string{"config-map1"},
+	}
+
+	serviceSpec := NewServiceSpec(serviceFlags, deployFlags)
+
+	conf, err := serviceSpec.Configuration()
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	cont := conf.Spec.RevisionTemplate.Spec.Container
+
+	expectedEnv := []corev1.EnvVar{
+		{Name: "KEY1", Value: "file1-val1"},
+		{Name: "KEY2", Value: "file2-val2"},
+		{Name: "KEY3", Value: "flag-val3"},
+	}
+
+	if !reflect.DeepEqual(cont.Env, expectedEnv) {
+		t.Fatalf("Expected env '%#v' to equal '%#v'", cont.Env, expectedEnv)
+	}
+
+	expectedEnvFrom := []corev1.EnvFromSource{
+		{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "config-map1"}}},
+		{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "secret1"}}},
+	}
+
+	if !reflect.DeepEqual(cont.EnvFrom, expectedEnvFrom) {
+		t.Fatalf("Expected env from '%#v' to equal '%#v'", cont.EnvFrom, expectedEnvFrom)
+	}
+
+	// Env from sources are not checked since they are empty
+	serviceSpec = NewServiceSpec(serviceFlags, DeployFlags{
+		Image:    "test-image",
+		EnvVars:  []string{"KEY3=flag-val3"},
+		EnvFiles: []string{envFile1, envFile2},
+	})
+
+	warnings, err := serviceSpec.EnvWarnings(nil)
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	expectedWarnings := []string{
+		"Environment variable 'KEY2' from env file '" + envFile2 + "' overrides value from env file '" + envFile1 + "'",
+		"Environment variable 'KEY3' from flags overrides value from env file '" + envFile1 + "'",
+	}
+
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Fatalf("Expected warnings '%#v' to equal '%#v'", warnings, expectedWarnings)
+	}
+}
+
+func writeTempFile(t *testing.T, content string) string {
+	file, err := ioutil.TempFile("", "knctl-test")
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	defer file.Close()
+
+	_, err = file.Write([]byte(content))
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	return file.Name()
+}
+
+func TestServiceSpecWithRelease(t *testing.T) {
+	serviceFlags := cmdflags.ServiceFlags{
+		NamespaceFlags: cmdcore.NamespaceFlags{
+			Name: "test-namespace",
+		},
+		Name: "test-service",
+	}
+
+	deployFlags := DeployFlags{
+		Image:        "test-image",
+		ManagedRoute: true,
+		Release:      true,
+	}
+
+	spec, err := NewServiceSpec(serviceFlags, deployFlags).WithReleaseRevisions([]string{"test-service-00001"}).Service()
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	if spec.Spec.RunLatest != nil || spec.Spec.Release == nil {
+		t.Fatalf("Expected service to be in release mode")
+	}
+
+	if !reflect.DeepEqual(spec.Spec.Release.Revisions, []string{"test-service-00001"}) {
+		t.Fatalf("Expected release revisions to include only current revision but was '%#v'", spec.Spec.Release.Revisions)
+	}
+
+	if spec.Spec.Release.Configuration.RevisionTemplate.Spec.Container.Image != "test-image" {
+		t.Fatalf("Expected release configuration to include image")
+	}
+
+	deployFlags.ManagedRoute = false
+
+	_, err = NewServiceSpec(serviceFlags, deployFlags).Service()
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	if err.Error() != "Expected '--release' to not be used with '--managed-route=false'" {
+		t.Fatalf("Expected release error but was '%s'", err)
+	}
+}
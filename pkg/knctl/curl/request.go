@@ -0,0 +1,156 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package curl
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+)
+
+type RequestOpts struct {
+	Method  string
+	Headers []string // in 'name: value' format
+	Data    []byte
+	Timeout time.Duration
+
+	IncludeHeaders bool
+	Verbose        bool
+
+	// Zero value means any status is accepted
+	ExpectStatus int
+}
+
+// Request sends HTTP request to given URL (typically ingress address)
+// with Host header set to a particular domain (service or route domain)
+type Request struct {
+	url  string
+	host string
+	opts RequestOpts
+}
+
+func NewRequest(url, host string, opts RequestOpts) Request {
+	return Request{url, host, opts}
+}
+
+func (r Request) Run(ui ui.UI) error {
+	req, err := r.build()
+	if err != nil {
+		return err
+	}
+
+	if r.opts.Verbose {
+		ui.PrintLinef("> %s %s", req.Method, req.URL.String())
+		ui.PrintLinef("> Host: %s", req.Host)
+		r.printHeaders(ui, "> ", req.Header)
+	}
+
+	client := &http.Client{
+		Timeout: r.opts.Timeout,
+		// Similar to curl, redirects are not followed
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Sending request: %s", err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Reading response body: %s", err)
+	}
+
+	if r.opts.Verbose || r.opts.IncludeHeaders {
+		prefix := ""
+		if r.opts.Verbose {
+			prefix = "< "
+		}
+
+		ui.PrintLinef("%s%s %s", prefix, resp.Proto, resp.Status)
+		r.printHeaders(ui, prefix, resp.Header)
+		ui.PrintLinef("")
+	}
+
+	ui.PrintBlock(body)
+
+	if r.opts.ExpectStatus > 0 && resp.StatusCode != r.opts.ExpectStatus {
+		return fmt.Errorf("Expected response status to be '%d' but was '%d'", r.opts.ExpectStatus, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (r Request) build() (*http.Request, error) {
+	method := r.opts.Method
+	if len(method) == 0 {
+		// Similar to curl, sending data implies POST
+		method = http.MethodGet
+		if r.opts.Data != nil {
+			method = http.MethodPost
+		}
+	}
+
+	req, err := http.NewRequest(strings.ToUpper(method), r.url, bytes.NewReader(r.opts.Data))
+	if err != nil {
+		return nil, fmt.Errorf("Building request: %s", err)
+	}
+
+	req.Host = r.host
+
+	for _, header := range r.opts.Headers {
+		pieces := strings.SplitN(header, ":", 2)
+		if len(pieces) != 2 || len(strings.TrimSpace(pieces[0])) == 0 {
+			return nil, fmt.Errorf("Expected header '%s' to be in format 'name: value'", header)
+		}
+
+		name := strings.TrimSpace(pieces[0])
+		value := strings.TrimSpace(pieces[1])
+
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+		} else {
+			req.Header.Add(name, value)
+		}
+	}
+
+	return req, nil
+}
+
+func (Request) printHeaders(ui ui.UI, prefix string, headers http.Header) {
+	var names []string
+
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, val := range headers[name] {
+			ui.PrintLinef("%s%s: %s", prefix, name, val)
+		}
+	}
+}
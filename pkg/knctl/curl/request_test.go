@@ -0,0 +1,175 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package curl_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	ctlcurl "github.com/cppforlife/knctl/pkg/knctl/curl"
+)
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		w.Header().Set("X-Test", "test-value")
+
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+		fmt.Fprintf(w, "method=%s host=%s path=%s custom=%s body=%s",
+			r.Method, r.Host, r.URL.Path, r.Header.Get("X-Custom"), body)
+	}))
+}
+
+func runRequest(url string, opts ctlcurl.RequestOpts) (string, error) {
+	outBuf := bytes.NewBufferString("")
+	writerUI := ui.NewWriterUI(outBuf, outBuf, ui.NewNoopLogger())
+
+	err := ctlcurl.NewRequest(url, "svc1.ns1.example.com", opts).Run(writerUI)
+
+	return outBuf.String(), err
+}
+
+func TestRequestSetsHostHeader(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	out, err := runRequest(server.URL, ctlcurl.RequestOpts{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	expectedOut := "method=GET host=svc1.ns1.example.com path=/ custom= body="
+	if out != expectedOut {
+		t.Fatalf("Expected output '%s' but was '%s'", expectedOut, out)
+	}
+}
+
+func TestRequestWithMethodHeadersAndData(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	opts := ctlcurl.RequestOpts{
+		Method:  "put",
+		Headers: []string{"X-Custom: custom-value", "Host: other.example.com"},
+		Data:    []byte("test-data"),
+		Timeout: time.Second,
+	}
+
+	out, err := runRequest(server.URL+"/path", opts)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	expectedOut := "method=PUT host=other.example.com path=/path custom=custom-value body=test-data"
+	if out != expectedOut {
+		t.Fatalf("Expected output '%s' but was '%s'", expectedOut, out)
+	}
+}
+
+func TestRequestWithDataDefaultsToPost(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	out, err := runRequest(server.URL, ctlcurl.RequestOpts{Data: []byte("test-data"), Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if !strings.HasPrefix(out, "method=POST ") {
+		t.Fatalf("Expected POST request but output was '%s'", out)
+	}
+}
+
+func TestRequestIncludeHeaders(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	out, err := runRequest(server.URL, ctlcurl.RequestOpts{IncludeHeaders: true, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if !strings.HasPrefix(out, "HTTP/1.1 200 OK\n") {
+		t.Fatalf("Expected output to start with status line but was '%s'", out)
+	}
+
+	if !strings.Contains(out, "\nX-Test: test-value\n") {
+		t.Fatalf("Expected output to include response headers but was '%s'", out)
+	}
+}
+
+func TestRequestExpectStatus(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	_, err := runRequest(server.URL+"/missing", ctlcurl.RequestOpts{ExpectStatus: 404, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	out, err := runRequest(server.URL+"/missing", ctlcurl.RequestOpts{ExpectStatus: 200, Timeout: time.Second})
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	expectedErr := "Expected response status to be '200' but was '404'"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+
+	if !strings.Contains(out, "path=/missing") {
+		t.Fatalf("Expected response body to be printed but was '%s'", out)
+	}
+}
+
+func TestRequestInvalidHeader(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	_, err := runRequest(server.URL, ctlcurl.RequestOpts{Headers: []string{"invalid"}})
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	expectedErr := "Expected header 'invalid' to be in format 'name: value'"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	_, err := runRequest(server.URL, ctlcurl.RequestOpts{Timeout: 50 * time.Millisecond})
+	if err == nil || !strings.HasPrefix(err.Error(), "Sending request: ") {
+		t.Fatalf("Expected timeout error but was '%v'", err)
+	}
+}
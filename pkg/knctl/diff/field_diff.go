@@ -0,0 +1,163 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+type FieldChangeType string
+
+const (
+	FieldChangeAdded   FieldChangeType = "added"
+	FieldChangeRemoved FieldChangeType = "removed"
+	FieldChangeChanged FieldChangeType = "changed"
+)
+
+type FieldChange struct {
+	Path     string
+	Type     FieldChangeType
+	OldValue string
+	NewValue string
+}
+
+// FieldDiff compares two JSON serializable objects field by field.
+// Lists of objects with a 'name' key (e.g. env variables) are compared by name
+// instead of by position, so that insertions do not show up as many changes.
+type FieldDiff struct {
+	changes []FieldChange
+}
+
+func NewFieldDiff(oldObj, newObj interface{}) (FieldDiff, error) {
+	oldFields, err := flattenObj(oldObj)
+	if err != nil {
+		return FieldDiff{}, err
+	}
+
+	newFields, err := flattenObj(newObj)
+	if err != nil {
+		return FieldDiff{}, err
+	}
+
+	var changes []FieldChange
+
+	for path, oldVal := range oldFields {
+		newVal, found := newFields[path]
+		switch {
+		case !found:
+			changes = append(changes, FieldChange{Path: path, Type: FieldChangeRemoved, OldValue: oldVal})
+		case oldVal != newVal:
+			changes = append(changes, FieldChange{Path: path, Type: FieldChangeChanged, OldValue: oldVal, NewValue: newVal})
+		}
+	}
+
+	for path, newVal := range newFields {
+		if _, found := oldFields[path]; !found {
+			changes = append(changes, FieldChange{Path: path, Type: FieldChangeAdded, NewValue: newVal})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	return FieldDiff{changes}, nil
+}
+
+func (d FieldDiff) Changes() []FieldChange { return d.changes }
+func (d FieldDiff) HasChanges() bool       { return len(d.changes) > 0 }
+
+func flattenObj(obj interface{}) (map[string]string, error) {
+	result := map[string]string{}
+
+	bs, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("Serializing object for diffing: %s", err)
+	}
+
+	var val interface{}
+
+	err = json.Unmarshal(bs, &val)
+	if err != nil {
+		return nil, fmt.Errorf("Deserializing object for diffing: %s", err)
+	}
+
+	flatten("", val, result)
+
+	return result, nil
+}
+
+func flatten(path string, val interface{}, result map[string]string) {
+	switch typedVal := val.(type) {
+	case nil:
+		// Missing and null values are treated the same
+
+	case map[string]interface{}:
+		for k, v := range typedVal {
+			if len(path) == 0 {
+				flatten(k, v, result)
+			} else {
+				flatten(path+"."+k, v, result)
+			}
+		}
+
+	case []interface{}:
+		names, byName := namesOfItems(typedVal)
+		for i, v := range typedVal {
+			if byName {
+				flatten(path+"["+names[i]+"]", v, result)
+			} else {
+				flatten(path+"["+strconv.Itoa(i)+"]", v, result)
+			}
+		}
+
+	case string:
+		// Empty strings are treated as missing since
+		// not all fields are marked as omitempty
+		if len(typedVal) > 0 {
+			result[path] = typedVal
+		}
+
+	default:
+		bs, _ := json.Marshal(typedVal)
+		result[path] = string(bs)
+	}
+}
+
+func namesOfItems(items []interface{}) ([]string, bool) {
+	var names []string
+	seen := map[string]struct{}{}
+
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		name, ok := obj["name"].(string)
+		if !ok || len(name) == 0 {
+			return nil, false
+		}
+		if _, found := seen[name]; found {
+			return nil, false
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+
+	return names, len(names) > 0
+}
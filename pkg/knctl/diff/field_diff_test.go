@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/cppforlife/knctl/pkg/knctl/diff"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestFieldDiff(t *testing.T) {
+	oldObj := corev1.Container{
+		Image: "image1",
+		Env: []corev1.EnvVar{
+			{Name: "key1", Value: "val1"},
+			{Name: "key2", Value: "val2"},
+		},
+		Args: []string{"arg1"},
+	}
+
+	newObj := corev1.Container{
+		Image: "image2",
+		Env: []corev1.EnvVar{
+			{Name: "key0", Value: "val0"},
+			{Name: "key1", Value: "val1"},
+		},
+		Args: []string{"arg1"},
+	}
+
+	diff, err := NewFieldDiff(oldObj, newObj)
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	if !diff.HasChanges() {
+		t.Fatalf("Expected diff to have changes")
+	}
+
+	expectedChanges := []FieldChange{
+		{Path: "env[key0].name", Type: FieldChangeAdded, NewValue: "key0"},
+		{Path: "env[key0].value", Type: FieldChangeAdded, NewValue: "val0"},
+		{Path: "env[key2].name", Type: FieldChangeRemoved, OldValue: "key2"},
+		{Path: "env[key2].value", Type: FieldChangeRemoved, OldValue: "val2"},
+		{Path: "image", Type: FieldChangeChanged, OldValue: "image1", NewValue: "image2"},
+	}
+
+	if !reflect.DeepEqual(diff.Changes(), expectedChanges) {
+		t.Fatalf("Expected changes '%#v' to equal '%#v'", diff.Changes(), expectedChanges)
+	}
+}
+
+func TestFieldDiffNoChanges(t *testing.T) {
+	obj := corev1.Container{Image: "image1", Args: []string{"arg1", "arg2"}}
+
+	diff, err := NewFieldDiff(obj, obj)
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	if diff.HasChanges() {
+		t.Fatalf("Expected diff to not have changes: %#v", diff.Changes())
+	}
+}
+
+func TestFieldDiffNilObject(t *testing.T) {
+	diff, err := NewFieldDiff(nil, corev1.Container{Image: "image1", Args: []string{"arg1"}})
+	if err != nil {
+		t.Fatalf("Expected error to not happen: %s", err)
+	}
+
+	expectedChanges := []FieldChange{
+		{Path: "args[0]", Type: FieldChangeAdded, NewValue: "arg1"},
+		{Path: "image", Type: FieldChangeAdded, NewValue: "image1"},
+	}
+
+	if !reflect.DeepEqual(diff.Changes(), expectedChanges) {
+		t.Fatalf("Expected changes '%#v' to equal '%#v'", diff.Changes(), expectedChanges)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/client/clientset/versioned/fake"
+	"github.com/knative/serving/pkg/client/clientset/versioned/scheme"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/testing"
+)
+
+// ServingClient is generated fake serving clientset backed by an object tracker
+// that additionally supports JSON and merge patches (generated one only supports
+// strategic merge patches which cannot be applied to custom resources).
+type ServingClient struct {
+	*fake.Clientset
+
+	tracker testing.ObjectTracker
+}
+
+func NewServingClient() *ServingClient {
+	tracker := testing.NewObjectTracker(scheme.Scheme, scheme.Codecs.UniversalDecoder())
+	client := &ServingClient{Clientset: fake.NewSimpleClientset(), tracker: tracker}
+
+	// Prepended reactors take precedence over ones installed by NewSimpleClientset
+	client.PrependReactor("*", "*", testing.ObjectReaction(tracker))
+	client.PrependReactor("patch", "*", client.patchReaction)
+
+	client.PrependWatchReactor("*", func(action testing.Action) (bool, watch.Interface, error) {
+		watcher, err := tracker.Watch(action.GetResource(), action.GetNamespace())
+		return true, watcher, err
+	})
+
+	return client
+}
+
+func (c *ServingClient) AddService(service v1alpha1.Service)          { c.Add(&service) }
+func (c *ServingClient) AddConfiguration(conf v1alpha1.Configuration) { c.Add(&conf) }
+func (c *ServingClient) AddRevision(revision v1alpha1.Revision)       { c.Add(&revision) }
+func (c *ServingClient) AddRoute(route v1alpha1.Route)                { c.Add(&route) }
+
+// Add creates or replaces objects bypassing reactors, hence
+// it's safe to call from AfterSave hooks
+func (c *ServingClient) Add(objs ...runtime.Object) {
+	for _, obj := range objs {
+		err := c.tracker.Add(obj)
+		if errors.IsAlreadyExists(err) {
+			objMeta, metaErr := meta.Accessor(obj)
+			if metaErr != nil {
+				panic(fmt.Sprintf("Accessing object meta: %s", metaErr))
+			}
+			err = c.tracker.Update(c.resource(obj), obj, objMeta.GetNamespace())
+		}
+		if err != nil {
+			panic(fmt.Sprintf("Adding object to fake serving client: %s", err))
+		}
+	}
+}
+
+// AfterSave registers hook that is invoked after object of given resource
+// (e.g. 'services') is created or updated to simulate controllers.
+// Hook receives saved object and should use Add to modify other objects.
+func (c *ServingClient) AfterSave(resource string, hook func(runtime.Object)) {
+	reaction := func(action testing.Action) (bool, runtime.Object, error) {
+		handled, obj, err := testing.ObjectReaction(c.tracker)(action)
+		if err != nil {
+			return handled, obj, err
+		}
+
+		hook(obj)
+
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return true, nil, err
+		}
+
+		// Return object as modified by hook
+		obj, err = c.tracker.Get(action.GetResource(), action.GetNamespace(), objMeta.GetName())
+		return true, obj, err
+	}
+
+	c.PrependReactor("create", resource, reaction)
+	c.PrependReactor("update", resource, reaction)
+}
+
+// ReconcileRoutes simulates route controller making
+// updated routes ready with their spec traffic
+func (c *ServingClient) ReconcileRoutes() {
+	c.AfterSave("routes", func(obj runtime.Object) {
+		route := obj.(*v1alpha1.Route).DeepCopy()
+		route.Status.Traffic = route.Spec.Traffic
+		route.Status.Conditions = duckv1alpha1.Conditions{
+			{Type: v1alpha1.RouteConditionReady, Status: corev1.ConditionTrue},
+		}
+		c.Add(route)
+	})
+}
+
+func (c *ServingClient) patchReaction(action testing.Action) (bool, runtime.Object, error) {
+	patchAction := action.(testing.PatchActionImpl)
+
+	obj, err := c.tracker.Get(action.GetResource(), action.GetNamespace(), patchAction.GetName())
+	if err != nil {
+		return true, nil, err
+	}
+
+	objBytes, err := json.Marshal(obj)
+	if err != nil {
+		return true, nil, err
+	}
+
+	patch := patchAction.GetPatch()
+
+	// Patch type is not recorded in the action; JSON patches are lists of operations
+	if len(patch) > 0 && patch[0] == '[' {
+		jsonPatch, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return true, nil, err
+		}
+		objBytes, err = jsonPatch.Apply(objBytes)
+	} else {
+		objBytes, err = jsonpatch.MergePatch(objBytes, patch)
+	}
+	if err != nil {
+		return true, nil, err
+	}
+
+	patchedObj := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(runtime.Object)
+
+	err = json.Unmarshal(objBytes, patchedObj)
+	if err != nil {
+		return true, nil, err
+	}
+
+	err = c.tracker.Update(action.GetResource(), patchedObj, action.GetNamespace())
+	if err != nil {
+		return true, nil, err
+	}
+
+	return true, patchedObj, nil
+}
+
+func (c *ServingClient) resource(obj runtime.Object) schema.GroupVersionResource {
+	gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+	if err != nil {
+		panic(fmt.Sprintf("Determining object kind: %s", err))
+	}
+	gvr, _ := meta.UnsafeGuessKindToResource(gvks[0])
+	return gvr
+}
+
+func (c *ServingClient) Service(namespace, name string) v1alpha1.Service {
+	obj := c.get(v1alpha1.SchemeGroupVersion.WithResource("services"), namespace, name)
+	return *obj.(*v1alpha1.Service)
+}
+
+func (c *ServingClient) Route(namespace, name string) v1alpha1.Route {
+	obj := c.get(v1alpha1.SchemeGroupVersion.WithResource("routes"), namespace, name)
+	return *obj.(*v1alpha1.Route)
+}
+
+// Revisions returns revisions in the order they were added
+func (c *ServingClient) Revisions(namespace string) []v1alpha1.Revision {
+	gvr := v1alpha1.SchemeGroupVersion.WithResource("revisions")
+
+	obj, err := c.tracker.List(gvr, v1alpha1.SchemeGroupVersion.WithKind("Revision"), namespace)
+	if err != nil {
+		panic(fmt.Sprintf("Listing revisions in fake serving client: %s", err))
+	}
+
+	return obj.(*v1alpha1.RevisionList).Items
+}
+
+func (c *ServingClient) get(gvr schema.GroupVersionResource, namespace, name string) runtime.Object {
+	obj, err := c.tracker.Get(gvr, namespace, name)
+	if err != nil {
+		panic(fmt.Sprintf("Getting object from fake serving client: %s", err))
+	}
+	return obj
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package load
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type GeneratorOpts struct {
+	RPS         int
+	Duration    time.Duration
+	Concurrency int
+	Timeout     time.Duration
+
+	// Requests slower than threshold are counted as cold starts
+	// (i.e. they most likely waited for a revision pod to start)
+	ColdStartThreshold time.Duration
+}
+
+// Generator sends requests at a constant rate to given URL
+// (typically ingress address) with Host header set to given domain
+type Generator struct {
+	url  string
+	host string
+	opts GeneratorOpts
+}
+
+func NewGenerator(url, host string, opts GeneratorOpts) Generator {
+	return Generator{url, host, opts}
+}
+
+func (g Generator) Run() Result {
+	client := &http.Client{
+		Timeout: g.opts.Timeout,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConnsPerHost: g.opts.Concurrency,
+		},
+	}
+
+	result := newResult(g.opts.ColdStartThreshold)
+	requestsCh := make(chan struct{}, g.opts.Concurrency)
+
+	var resultLock sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < g.opts.Concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range requestsCh {
+				reqResult := g.request(client)
+
+				resultLock.Lock()
+				result.add(reqResult)
+				resultLock.Unlock()
+			}
+		}()
+	}
+
+	startTime := time.Now()
+	ticker := time.NewTicker(time.Second / time.Duration(g.opts.RPS))
+
+	for time.Since(startTime) < g.opts.Duration {
+		<-ticker.C
+		// Rate drops below requested if all workers are busy
+		requestsCh <- struct{}{}
+	}
+
+	ticker.Stop()
+	close(requestsCh)
+	wg.Wait()
+
+	result.Duration = time.Since(startTime)
+
+	return *result
+}
+
+func (g Generator) request(client *http.Client) requestResult {
+	req, err := http.NewRequest(http.MethodGet, g.url, nil)
+	if err != nil {
+		return requestResult{Err: err}
+	}
+
+	req.Host = g.host
+
+	startTime := time.Now()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return requestResult{Err: err, Latency: time.Since(startTime)}
+	}
+
+	// Read body so that connection can be reused
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	return requestResult{StatusCode: resp.StatusCode, Latency: time.Since(startTime)}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package load_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ctlload "github.com/cppforlife/knctl/pkg/knctl/load"
+)
+
+func TestGeneratorRun(t *testing.T) {
+	var requests int32
+	var hosts atomic.Value
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts.Store(r.Host)
+
+		switch atomic.AddInt32(&requests, 1) {
+		case 1:
+			time.Sleep(200 * time.Millisecond) // simulate cold start
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	opts := ctlload.GeneratorOpts{
+		RPS:                20,
+		Duration:           500 * time.Millisecond,
+		Concurrency:        2,
+		Timeout:            time.Second,
+		ColdStartThreshold: 150 * time.Millisecond,
+	}
+
+	result := ctlload.NewGenerator(server.URL, "svc1.ns1.example.com", opts).Run()
+
+	if result.Requests < 5 || int32(result.Requests) != atomic.LoadInt32(&requests) {
+		t.Fatalf("Expected requests to be sent but was '%d' (server received '%d')", result.Requests, requests)
+	}
+
+	if result.Errors != 0 {
+		t.Fatalf("Expected no errors but was '%d'", result.Errors)
+	}
+
+	if result.StatusCodes[http.StatusServiceUnavailable] != 1 || result.StatusCodes[http.StatusOK] != result.Requests-1 {
+		t.Fatalf("Expected one failed request but status codes were '%#v'", result.StatusCodes)
+	}
+
+	if result.ColdStarts != 1 {
+		t.Fatalf("Expected one cold start but was '%d'", result.ColdStarts)
+	}
+
+	if hosts.Load().(string) != "svc1.ns1.example.com" {
+		t.Fatalf("Expected Host header to be set but was '%s'", hosts.Load())
+	}
+}
+
+func TestGeneratorRunWithErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	opts := ctlload.GeneratorOpts{RPS: 10, Duration: 300 * time.Millisecond, Concurrency: 1, Timeout: time.Second}
+
+	result := ctlload.NewGenerator(server.URL, "svc1.ns1.example.com", opts).Run()
+
+	if result.Requests == 0 || result.Errors != result.Requests || len(result.Latencies) != 0 {
+		t.Fatalf("Expected all requests to fail but result was '%#v'", result)
+	}
+}
+
+func TestResultPercentile(t *testing.T) {
+	result := ctlload.Result{}
+
+	if result.Percentile(50) != 0 {
+		t.Fatalf("Expected zero percentile without latencies")
+	}
+
+	for i := 10; i >= 1; i-- {
+		result.Latencies = append(result.Latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	examples := map[float64]time.Duration{
+		50:  5 * time.Millisecond,
+		90:  9 * time.Millisecond,
+		99:  10 * time.Millisecond,
+		100: 10 * time.Millisecond,
+	}
+
+	for percent, expected := range examples {
+		if result.Percentile(percent) != expected {
+			t.Fatalf("Expected p%v to be '%s' but was '%s'", percent, expected, result.Percentile(percent))
+		}
+	}
+}
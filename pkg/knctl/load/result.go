@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package load
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+type Result struct {
+	Duration time.Duration
+
+	Requests    int
+	Errors      int
+	ColdStarts  int
+	StatusCodes map[int]int
+
+	// Latencies of requests that received a response
+	Latencies []time.Duration
+
+	coldStartThreshold time.Duration
+}
+
+type requestResult struct {
+	StatusCode int
+	Latency    time.Duration
+	Err        error
+}
+
+func newResult(coldStartThreshold time.Duration) *Result {
+	return &Result{StatusCodes: map[int]int{}, coldStartThreshold: coldStartThreshold}
+}
+
+func (r *Result) add(reqResult requestResult) {
+	r.Requests++
+
+	if reqResult.Err != nil {
+		r.Errors++
+		return
+	}
+
+	r.StatusCodes[reqResult.StatusCode]++
+	r.Latencies = append(r.Latencies, reqResult.Latency)
+
+	if r.coldStartThreshold > 0 && reqResult.Latency >= r.coldStartThreshold {
+		r.ColdStarts++
+	}
+}
+
+// RPS returns actual rate of sent requests
+func (r Result) RPS() float64 {
+	if r.Duration == 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Duration.Seconds()
+}
+
+// Percentile returns latency below which given percentage of requests fall
+// (nearest-rank method); zero is returned if there are no latencies
+func (r Result) Percentile(percent float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+
+	sorted := append([]time.Duration{}, r.Latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(percent/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+
+	return sorted[rank]
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+)
+
+// AddressFunc returns base URL (e.g. http://1.2.3.4:80) requests are forwarded to
+type AddressFunc func() (string, error)
+
+type ProxyOpts struct {
+	// How often address is re-resolved (e.g. when ingress gets new IP)
+	ResolveInterval time.Duration
+}
+
+// Proxy forwards requests to an address (typically ingress address)
+// with Host header rewritten to given domain, so that services
+// can be reached without configuring DNS
+type Proxy struct {
+	host     string
+	addrFunc AddressFunc
+	opts     ProxyOpts
+	ui       ui.UI
+
+	addrLock sync.RWMutex
+	addr     *url.URL
+
+	uiLock sync.Mutex
+}
+
+func NewProxy(host string, addrFunc AddressFunc, opts ProxyOpts, ui ui.UI) *Proxy {
+	return &Proxy{host: host, addrFunc: addrFunc, opts: opts, ui: ui}
+}
+
+// Addr returns currently used address
+func (p *Proxy) Addr() string {
+	p.addrLock.RLock()
+	defer p.addrLock.RUnlock()
+
+	if p.addr == nil {
+		return ""
+	}
+	return p.addr.String()
+}
+
+// Serve accepts connections on given listener until cancelCh is closed.
+// Address is resolved first unless Resolve was already called.
+func (p *Proxy) Serve(listener net.Listener, cancelCh chan struct{}) error {
+	if len(p.Addr()) == 0 {
+		err := p.Resolve()
+		if err != nil {
+			return err
+		}
+	}
+
+	server := &http.Server{Handler: p.loggingHandler(p.reverseProxy())}
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+
+	var ticker *time.Ticker
+	var tickerCh <-chan time.Time
+
+	if p.opts.ResolveInterval > 0 {
+		ticker = time.NewTicker(p.opts.ResolveInterval)
+		tickerCh = ticker.C
+		defer ticker.Stop()
+	}
+
+	for {
+		select {
+		case <-tickerCh:
+			err := p.Resolve()
+			if err != nil {
+				p.printLinef("Resolving address: %s", err)
+			}
+
+		case err := <-errCh:
+			return err
+
+		case <-cancelCh:
+			server.Close()
+			<-errCh
+			return nil
+		}
+	}
+}
+
+// Resolve updates address requests are forwarded to
+func (p *Proxy) Resolve() error {
+	addr, err := p.addrFunc()
+	if err != nil {
+		return err
+	}
+
+	parsedAddr, err := url.Parse(addr)
+	if err != nil {
+		return fmt.Errorf("Parsing address '%s': %s", addr, err)
+	}
+
+	prevAddr := p.Addr()
+
+	p.addrLock.Lock()
+	p.addr = parsedAddr
+	p.addrLock.Unlock()
+
+	if len(prevAddr) > 0 && prevAddr != parsedAddr.String() {
+		p.printLinef("Address changed from '%s' to '%s'", prevAddr, parsedAddr)
+	}
+
+	return nil
+}
+
+func (p *Proxy) reverseProxy() *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			p.addrLock.RLock()
+			req.URL.Scheme = p.addr.Scheme
+			req.URL.Host = p.addr.Host
+			p.addrLock.RUnlock()
+
+			req.Host = p.host
+		},
+
+		// Flush immediately to support streaming responses
+		FlushInterval: -1,
+
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			p.printLinef("Forwarding %s %s: %s", req.Method, req.URL.Path, err)
+
+			// Address may have changed since last resolution
+			err = p.Resolve()
+			if err != nil {
+				p.printLinef("Resolving address: %s", err)
+			}
+
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+}
+
+func (p *Proxy) loggingHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		startTime := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		handler.ServeHTTP(recorder, req)
+
+		p.printLinef("%s %s %d %s", req.Method, req.URL.RequestURI(),
+			recorder.status, time.Since(startTime).Round(time.Millisecond))
+	})
+}
+
+func (p *Proxy) printLinef(pattern string, args ...interface{}) {
+	p.uiLock.Lock()
+	defer p.uiLock.Unlock()
+
+	p.ui.PrintLinef(pattern, args...)
+}
+
+// statusRecorder keeps response status for logging purposes.
+// Flushing and hijacking is delegated to support streaming and WebSockets.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("Expected response writer to support hijacking")
+	}
+	// Upgrade response is written directly to hijacked connection
+	r.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
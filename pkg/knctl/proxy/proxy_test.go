@@ -0,0 +1,248 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy_test
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	ctlproxy "github.com/cppforlife/knctl/pkg/knctl/proxy"
+)
+
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func startProxy(t *testing.T, addrFunc ctlproxy.AddressFunc, opts ctlproxy.ProxyOpts) (*ctlproxy.Proxy, string, *syncBuffer, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	out := &syncBuffer{}
+	proxy := ctlproxy.NewProxy("svc1.ns1.example.com", addrFunc, opts, ui.NewWriterUI(out, out, ui.NewNoopLogger()))
+
+	err = proxy.Resolve()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	cancelCh := make(chan struct{})
+	doneCh := make(chan error, 1)
+
+	go func() {
+		doneCh <- proxy.Serve(listener, cancelCh)
+	}()
+
+	stopFunc := func() {
+		close(cancelCh)
+
+		err := <-doneCh
+		if err != nil {
+			t.Fatalf("Expected no error: %s", err)
+		}
+	}
+
+	return proxy, "http://" + listener.Addr().String(), out, stopFunc
+}
+
+func TestProxyRewritesHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%s %s", req.Host, req.URL.RequestURI())
+	}))
+	defer server.Close()
+
+	_, proxyURL, out, stopFunc := startProxy(t, func() (string, error) { return server.URL, nil }, ctlproxy.ProxyOpts{})
+	defer stopFunc()
+
+	resp, err := http.Get(proxyURL + "/path?a=1")
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 but was %d", resp.StatusCode)
+	}
+
+	if string(body) != "svc1.ns1.example.com /path?a=1" {
+		t.Fatalf("Expected request to be forwarded with rewritten host but was '%s'", body)
+	}
+
+	if !strings.Contains(out.String(), "GET /path?a=1 201") {
+		t.Fatalf("Expected request to be logged but was '%s'", out.String())
+	}
+}
+
+func TestProxyReresolvesAddress(t *testing.T) {
+	server1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "server1")
+	}))
+	defer server1.Close()
+
+	server2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "server2")
+	}))
+	defer server2.Close()
+
+	var addrLock sync.Mutex
+	addr := server1.URL
+
+	addrFunc := func() (string, error) {
+		addrLock.Lock()
+		defer addrLock.Unlock()
+		return addr, nil
+	}
+
+	proxy, _, out, stopFunc := startProxy(t, addrFunc, ctlproxy.ProxyOpts{ResolveInterval: 10 * time.Millisecond})
+	defer stopFunc()
+
+	if proxy.Addr() != server1.URL {
+		t.Fatalf("Expected address '%s' but was '%s'", server1.URL, proxy.Addr())
+	}
+
+	addrLock.Lock()
+	addr = server2.URL
+	addrLock.Unlock()
+
+	for i := 0; i < 100 && proxy.Addr() != server2.URL; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if proxy.Addr() != server2.URL {
+		t.Fatalf("Expected address '%s' but was '%s'", server2.URL, proxy.Addr())
+	}
+
+	if !strings.Contains(out.String(), "Address changed from '"+server1.URL+"' to '"+server2.URL+"'") {
+		t.Fatalf("Expected address change to be logged but was '%s'", out.String())
+	}
+}
+
+func TestProxyUpgradesConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Upgrade") != "test-protocol" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test-protocol\r\n\r\n")
+		rw.Flush()
+
+		line, _ := rw.ReadString('\n')
+		fmt.Fprintf(rw, "echo %s", line)
+		rw.Flush()
+	}))
+	defer server.Close()
+
+	_, proxyURL, out, stopFunc := startProxy(t, func() (string, error) { return server.URL, nil }, ctlproxy.ProxyOpts{})
+	defer stopFunc()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(proxyURL, "http://"))
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: test-protocol\r\n\r\n")
+
+	reader := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status 101 but was %d", resp.StatusCode)
+	}
+
+	fmt.Fprintf(conn, "hello\n")
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if line != "echo hello\n" {
+		t.Fatalf("Expected echoed line but was '%s'", line)
+	}
+
+	conn.Close()
+
+	for i := 0; i < 100 && !strings.Contains(out.String(), "GET / 101"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !strings.Contains(out.String(), "GET / 101") {
+		t.Fatalf("Expected upgraded request to be logged but was '%s'", out.String())
+	}
+}
+
+func TestProxyBadGateway(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	_, proxyURL, out, stopFunc := startProxy(t, func() (string, error) { return serverURL, nil }, ctlproxy.ProxyOpts{})
+	defer stopFunc()
+
+	resp, err := http.Get(proxyURL + "/")
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("Expected status 502 but was %d", resp.StatusCode)
+	}
+
+	if !strings.Contains(out.String(), "GET / 502") {
+		t.Fatalf("Expected request to be logged but was '%s'", out.String())
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+// RevisionConfigurationGeneration returns generation of the configuration
+// that produced given revision. Newer Knative versions record it as a label,
+// older ones as an annotation.
+func RevisionConfigurationGeneration(revision v1alpha1.Revision) (int64, bool) {
+	for _, src := range []map[string]string{revision.Labels, revision.Annotations} {
+		if val, found := src[serving.ConfigurationGenerationAnnotationKey]; found {
+			gen, err := strconv.ParseInt(val, 10, 64)
+			if err == nil {
+				return gen, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// SortRevisionsByGeneration orders revisions from newest to oldest.
+// Creation timestamps are only used when generations are not known
+// since multiple revisions may be created within the same second.
+func SortRevisionsByGeneration(revisions []v1alpha1.Revision) {
+	sort.SliceStable(revisions, func(i, j int) bool {
+		genI, _ := RevisionConfigurationGeneration(revisions[i])
+		genJ, _ := RevisionConfigurationGeneration(revisions[j])
+		if genI != genJ {
+			return genI > genJ
+		}
+
+		timeI := revisions[i].CreationTimestamp.Time
+		timeJ := revisions[j].CreationTimestamp.Time
+		if !timeI.Equal(timeJ) {
+			return timeI.After(timeJ)
+		}
+
+		// Revision names include zero padded sequence number
+		return revisions[i].Name > revisions[j].Name
+	})
+}
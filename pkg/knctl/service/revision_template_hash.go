@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	apirand "k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	RevisionTemplateHashAnnotationKey  = "cli.knative.dev/revisionTemplateHash"
+	RevisionTemplateForceAnnotationKey = "cli.knative.dev/forceDeploy"
+)
+
+var revisionTemplateAnnotationKeys = []string{
+	RevisionTemplateHashAnnotationKey,
+	RevisionTemplateForceAnnotationKey,
+}
+
+// RevisionTemplateHash returns stable hash of desired configuration spec.
+// Annotations managed by knctl and server managed fields are not included.
+func RevisionTemplateHash(spec v1alpha1.ConfigurationSpec) (string, error) {
+	spec = *spec.DeepCopy()
+	spec.Generation = 0
+
+	WithoutRevisionTemplateAnnotations(&spec)
+
+	// Map keys are sorted during JSON serialization
+	bytes, err := json.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("Serializing configuration spec: %s", err)
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(bytes)), nil
+}
+
+// WithoutRevisionTemplateAnnotations removes annotations managed by knctl
+func WithoutRevisionTemplateAnnotations(spec *v1alpha1.ConfigurationSpec) {
+	anns := spec.RevisionTemplate.Annotations
+
+	for _, key := range revisionTemplateAnnotationKeys {
+		delete(anns, key)
+	}
+
+	if len(anns) == 0 {
+		spec.RevisionTemplate.Annotations = nil
+	}
+}
+
+func withRevisionTemplateAnnotations(spec *v1alpha1.ConfigurationSpec, force bool) (string, error) {
+	hash, err := RevisionTemplateHash(*spec)
+	if err != nil {
+		return "", err
+	}
+
+	anns := map[string]string{}
+
+	for k, v := range spec.RevisionTemplate.Annotations {
+		anns[k] = v
+	}
+
+	anns[RevisionTemplateHashAnnotationKey] = hash
+
+	// Unchanged spec does not result in a new revision, hence add random value
+	if force {
+		anns[RevisionTemplateForceAnnotationKey] = apirand.String(10)
+	}
+
+	spec.RevisionTemplate.Annotations = anns
+
+	return hash, nil
+}
@@ -18,7 +18,7 @@ package service
 
 import (
 	"fmt"
-	"sort"
+	"time"
 
 	ctlbuild "github.com/cppforlife/knctl/pkg/knctl/build"
 	buildv1alpha1 "github.com/knative/build/pkg/apis/build/v1alpha1"
@@ -26,6 +26,7 @@ import (
 	"github.com/knative/serving/pkg/apis/serving"
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -46,6 +47,14 @@ type Service struct {
 	buildClient     buildclientset.Interface
 	coreClient      kubernetes.Interface
 	buildObjFactory ctlbuild.Factory
+
+	revisionCreationTimeout time.Duration
+
+	// Populated by CreateOrUpdate
+	deployedName     string
+	deployedTemplate *v1alpha1.RevisionTemplateSpec
+	prevGeneration   int64
+	prevServiceSpec  *v1alpha1.ServiceSpec
 }
 
 func NewService(
@@ -55,7 +64,22 @@ func NewService(
 	coreClient kubernetes.Interface,
 	buildObjFactory ctlbuild.Factory,
 ) *Service {
-	return &Service{serviceSpec, servingClient, buildClient, coreClient, buildObjFactory}
+	return &Service{
+		serviceSpec:     serviceSpec,
+		servingClient:   servingClient,
+		buildClient:     buildClient,
+		coreClient:      coreClient,
+		buildObjFactory: buildObjFactory,
+
+		revisionCreationTimeout: 2 * time.Minute,
+	}
+}
+
+// WithRevisionCreationTimeout changes how long CreatedRevisionSinceRevision
+// waits for configuration controller to create new revision
+func (l *Service) WithRevisionCreationTimeout(timeout time.Duration) *Service {
+	l.revisionCreationTimeout = timeout
+	return l
 }
 
 func (l *Service) CreatedBuildSinceRevision(lastRevision *v1alpha1.Revision) (ctlbuild.Build, error) {
@@ -98,7 +122,24 @@ func (l *Service) CreatedBuildSinceRevision(lastRevision *v1alpha1.Revision) (ct
 	return ctlbuild.Build{}, fmt.Errorf("Expected to find new build")
 }
 
+// CreatedRevisionSinceRevision finds revision produced by the last CreateOrUpdate call.
+// Revision is identified by its configuration generation (which must be newer than
+// generation observed before the update) and its annotations (which must match deployed
+// template), so that concurrent deploys of the same service do not pick up each other's
+// revisions. Returns an error if revision is not created within a timeout.
 func (l *Service) CreatedRevisionSinceRevision(lastRevision *v1alpha1.Revision) (*v1alpha1.Revision, error) {
+	if l.deployedTemplate == nil {
+		return nil, fmt.Errorf("Expected service to be deployed before finding created revision")
+	}
+
+	minGeneration := l.prevGeneration
+
+	if lastRevision != nil {
+		if gen, found := RevisionConfigurationGeneration(*lastRevision); found && gen > minGeneration {
+			minGeneration = gen
+		}
+	}
+
 	cancelResWatchCh := make(chan struct{})
 	revisionsToWatchCh := make(chan v1alpha1.Revision)
 
@@ -108,7 +149,7 @@ func (l *Service) CreatedRevisionSinceRevision(lastRevision *v1alpha1.Revision)
 			l.servingClient.ServingV1alpha1().Revisions(l.serviceSpec.Namespace()),
 			metav1.ListOptions{
 				LabelSelector: labels.Set(map[string]string{
-					serving.ConfigurationLabelKey: l.serviceSpec.Name(),
+					serving.ConfigurationLabelKey: l.deployedName,
 				}).String(),
 			},
 		)
@@ -122,45 +163,85 @@ func (l *Service) CreatedRevisionSinceRevision(lastRevision *v1alpha1.Revision)
 		close(revisionsToWatchCh)
 	}()
 
-	var createdRevision *v1alpha1.Revision
+	defer func() {
+		close(cancelResWatchCh)
+		// Unblock watcher if it's still sending revisions
+		go func() {
+			for range revisionsToWatchCh {
+			}
+		}()
+	}()
 
-	for revision := range revisionsToWatchCh {
-		// TODO comparing based on time causes problems for revisions with same creation timestamp
-		if lastRevision == nil || revision.CreationTimestamp.Time.After(lastRevision.CreationTimestamp.Time) {
-			createdRevision = &revision
-			break
-		}
-	}
+	timeoutCh := time.After(l.revisionCreationTimeout)
 
-	close(cancelResWatchCh)
+	for {
+		select {
+		case revision, ok := <-revisionsToWatchCh:
+			if !ok {
+				return nil, fmt.Errorf("Expected to find created revision")
+			}
 
-	if createdRevision == nil {
-		return nil, fmt.Errorf("Expected to find created revision")
+			gen, found := RevisionConfigurationGeneration(revision)
+			if found && gen > minGeneration && l.matchesDeployedTemplate(revision) {
+				return &revision, nil
+			}
+
+		case <-timeoutCh:
+			return nil, fmt.Errorf("Expected to find created revision within %s", l.revisionCreationTimeout)
+		}
 	}
+}
 
-	return createdRevision, nil
+// matchesDeployedTemplate checks revision annotations since deployed template
+// is annotated with its hash (revision spec is not compared as it's defaulted by the server)
+func (l *Service) matchesDeployedTemplate(revision v1alpha1.Revision) bool {
+	for k, v := range l.deployedTemplate.Annotations {
+		if revision.Annotations[k] != v {
+			return false
+		}
+	}
+	return true
 }
 
+// LastRevision returns most recently created revision based on
+// configuration's status or configuration generations of revisions
 func (l *Service) LastRevision() (*v1alpha1.Revision, error) {
+	conf, err := l.servingClient.ServingV1alpha1().Configurations(l.serviceSpec.Namespace()).Get(l.serviceSpec.Name(), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Getting configuration: %s", err)
+	}
+
+	// LatestCreatedRevisionName is only trustworthy once configuration controller
+	// caught up with the latest configuration spec
+	if conf.Status.ObservedGeneration == conf.Spec.Generation && len(conf.Status.LatestCreatedRevisionName) > 0 {
+		revision, err := l.servingClient.ServingV1alpha1().Revisions(l.serviceSpec.Namespace()).Get(conf.Status.LatestCreatedRevisionName, metav1.GetOptions{})
+		if err == nil {
+			return revision, nil
+		}
+		if !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("Getting revision: %s", err)
+		}
+	}
+
 	listOpts := metav1.ListOptions{
 		LabelSelector: labels.Set(map[string]string{
 			serving.ConfigurationLabelKey: l.serviceSpec.Name(),
 		}).String(),
 	}
 
-	// TODO LatestCreatedRevisionName may not be updated that quickly
 	revisions, err := l.servingClient.ServingV1alpha1().Revisions(l.serviceSpec.Namespace()).List(listOpts)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Listing revisions: %s", err)
 	}
 
 	if len(revisions.Items) == 0 {
 		return nil, nil
 	}
 
-	sort.Slice(revisions.Items, func(i, j int) bool {
-		return revisions.Items[i].CreationTimestamp.Time.After(revisions.Items[j].CreationTimestamp.Time)
-	})
+	SortRevisionsByGeneration(revisions.Items)
 
 	revision := revisions.Items[0]
 	return &revision, nil
@@ -0,0 +1,182 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cppforlife/knctl/pkg/knctl/util"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Knative allows up to 99% of traffic to be sent to candidate revision
+	ReleaseMaxRolloutPercent = 99
+)
+
+// ServiceConfigurationSpec returns configuration spec embedded in the service
+// (nil for services with manually managed configurations)
+func ServiceConfigurationSpec(spec *v1alpha1.ServiceSpec) *v1alpha1.ConfigurationSpec {
+	switch {
+	case spec.RunLatest != nil:
+		return &spec.RunLatest.Configuration
+	case spec.Pinned != nil:
+		return &spec.Pinned.Configuration
+	case spec.Release != nil:
+		return &spec.Release.Configuration
+	default:
+		return nil
+	}
+}
+
+func ValidateReleaseRolloutPercent(rolloutPercent int) error {
+	if rolloutPercent < 0 || rolloutPercent > ReleaseMaxRolloutPercent {
+		return fmt.Errorf("Expected rollout percentage '%d' to be between 0%% and %d%%", rolloutPercent, ReleaseMaxRolloutPercent)
+	}
+	return nil
+}
+
+// ServiceRelease manages revisions of a service in release mode.
+// First release revision is 'current' and second (optional) one is 'candidate'.
+type ServiceRelease struct {
+	servingClient servingclientset.Interface
+	namespace     string
+	name          string
+}
+
+func NewServiceRelease(servingClient servingclientset.Interface, namespace, name string) ServiceRelease {
+	return ServiceRelease{servingClient, namespace, name}
+}
+
+// CurrentRevisionName returns revision that should stay current when new
+// candidate revision is deployed: current revision of a service in release mode,
+// or latest ready revision otherwise.
+func (r ServiceRelease) CurrentRevisionName() (string, error) {
+	service, err := r.servingClient.ServingV1alpha1().Services(r.namespace).Get(r.name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", fmt.Errorf("Expected service '%s' to exist before releasing candidate revision "+
+				"(deploy without '--release' first)", r.name)
+		}
+		return "", fmt.Errorf("Getting service: %s", err)
+	}
+
+	if service.Spec.Release != nil && len(service.Spec.Release.Revisions) > 0 {
+		return service.Spec.Release.Revisions[0], nil
+	}
+
+	if len(service.Status.LatestReadyRevisionName) == 0 {
+		return "", fmt.Errorf("Expected service '%s' to have a ready revision before releasing candidate revision", r.name)
+	}
+
+	return service.Status.LatestReadyRevisionName, nil
+}
+
+// SetCandidate sends given percentage of traffic to candidate revision
+func (r ServiceRelease) SetCandidate(revisionName string, rolloutPercent int) error {
+	err := ValidateReleaseRolloutPercent(rolloutPercent)
+	if err != nil {
+		return err
+	}
+
+	return r.update(func(release *v1alpha1.ReleaseType) error {
+		if len(release.Revisions) == 0 {
+			return fmt.Errorf("Expected service '%s' to have current revision", r.name)
+		}
+
+		release.Revisions = []string{release.Revisions[0], revisionName}
+		release.RolloutPercent = rolloutPercent
+
+		return nil
+	})
+}
+
+// SetRolloutPercent changes percentage of traffic sent to candidate revision
+func (r ServiceRelease) SetRolloutPercent(rolloutPercent int) error {
+	err := ValidateReleaseRolloutPercent(rolloutPercent)
+	if err != nil {
+		return err
+	}
+
+	return r.update(func(release *v1alpha1.ReleaseType) error {
+		if len(release.Revisions) < 2 {
+			return fmt.Errorf("Expected service '%s' to have candidate revision", r.name)
+		}
+
+		release.RolloutPercent = rolloutPercent
+
+		return nil
+	})
+}
+
+// Promote makes candidate revision current and sends all traffic to it.
+// Returns name of the promoted revision.
+func (r ServiceRelease) Promote() (string, error) {
+	var promotedName string
+
+	err := r.update(func(release *v1alpha1.ReleaseType) error {
+		if len(release.Revisions) < 2 {
+			return fmt.Errorf("Expected service '%s' to have candidate revision", r.name)
+		}
+
+		promotedName = release.Revisions[1]
+
+		release.Revisions = []string{promotedName}
+		release.RolloutPercent = 0
+
+		return nil
+	})
+
+	return promotedName, err
+}
+
+func (r ServiceRelease) update(updateFunc func(*v1alpha1.ReleaseType) error) error {
+	var updateErr error
+
+	retryErr := util.Retry(time.Second, 10*time.Second, func() (bool, error) {
+		service, err := r.servingClient.ServingV1alpha1().Services(r.namespace).Get(r.name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("Getting service: %s", err)
+		}
+
+		if service.Spec.Release == nil {
+			updateErr = fmt.Errorf("Expected service '%s' to be in release mode (use '--release' flag when running 'deploy' command)", r.name)
+			return true, nil
+		}
+
+		updateErr = updateFunc(service.Spec.Release)
+		if updateErr != nil {
+			return true, nil
+		}
+
+		_, err = r.servingClient.ServingV1alpha1().Services(r.namespace).Update(service)
+		if err != nil {
+			return false, fmt.Errorf("Updating service: %s", err)
+		}
+
+		return true, nil
+	})
+	if retryErr != nil {
+		return retryErr
+	}
+
+	return updateErr
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cppforlife/knctl/pkg/knctl/fakes"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testReleaseService(revisions []string, rolloutPercent int) v1alpha1.Service {
+	return v1alpha1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-service", Namespace: "test-namespace"},
+		Spec: v1alpha1.ServiceSpec{
+			Release: &v1alpha1.ReleaseType{Revisions: revisions, RolloutPercent: rolloutPercent},
+		},
+	}
+}
+
+func TestServiceReleaseCurrentRevisionName(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+	release := ctlservice.NewServiceRelease(servingClient, "test-namespace", "test-service")
+
+	_, err := release.CurrentRevisionName()
+	if err == nil || err.Error() != "Expected service 'test-service' to exist before releasing candidate revision (deploy without '--release' first)" {
+		t.Fatalf("Expected missing service error but was '%s'", err)
+	}
+
+	runLatestService := v1alpha1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-service", Namespace: "test-namespace"},
+		Spec:       v1alpha1.ServiceSpec{RunLatest: &v1alpha1.RunLatestType{}},
+	}
+	runLatestService.Status.LatestReadyRevisionName = "test-service-00002"
+
+	servingClient.AddService(runLatestService)
+
+	name, err := release.CurrentRevisionName()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if name != "test-service-00002" {
+		t.Fatalf("Expected current revision to be latest ready revision but was '%s'", name)
+	}
+
+	servingClient.AddService(testReleaseService([]string{"test-service-00001", "test-service-00002"}, 10))
+
+	name, err = release.CurrentRevisionName()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if name != "test-service-00001" {
+		t.Fatalf("Expected current revision to be first release revision but was '%s'", name)
+	}
+}
+
+func TestServiceReleaseSetCandidateAndPromote(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+	servingClient.AddService(testReleaseService([]string{"test-service-00001"}, 0))
+
+	release := ctlservice.NewServiceRelease(servingClient, "test-namespace", "test-service")
+
+	err := release.SetCandidate("test-service-00002", 10)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	expectedRelease := &v1alpha1.ReleaseType{Revisions: []string{"test-service-00001", "test-service-00002"}, RolloutPercent: 10}
+
+	if !reflect.DeepEqual(servingClient.Service("test-namespace", "test-service").Spec.Release, expectedRelease) {
+		t.Fatalf("Expected release '%#v' to equal '%#v'", servingClient.Service("test-namespace", "test-service").Spec.Release, expectedRelease)
+	}
+
+	err = release.SetRolloutPercent(50)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if servingClient.Service("test-namespace", "test-service").Spec.Release.RolloutPercent != 50 {
+		t.Fatalf("Expected rollout percent to be updated")
+	}
+
+	promotedName, err := release.Promote()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if promotedName != "test-service-00002" {
+		t.Fatalf("Expected promoted revision to be 'test-service-00002' but was '%s'", promotedName)
+	}
+
+	expectedRelease = &v1alpha1.ReleaseType{Revisions: []string{"test-service-00002"}, RolloutPercent: 0}
+
+	if !reflect.DeepEqual(servingClient.Service("test-namespace", "test-service").Spec.Release, expectedRelease) {
+		t.Fatalf("Expected release '%#v' to equal '%#v'", servingClient.Service("test-namespace", "test-service").Spec.Release, expectedRelease)
+	}
+}
+
+func TestServiceReleaseErrors(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+	servingClient.AddService(testReleaseService([]string{"test-service-00001"}, 0))
+
+	release := ctlservice.NewServiceRelease(servingClient, "test-namespace", "test-service")
+
+	examples := []struct {
+		Desc        string
+		Func        func() error
+		ExpectedErr string
+	}{
+		{
+			Desc:        "promote without candidate",
+			Func:        func() error { _, err := release.Promote(); return err },
+			ExpectedErr: "Expected service 'test-service' to have candidate revision",
+		},
+		{
+			Desc:        "rollout percent without candidate",
+			Func:        func() error { return release.SetRolloutPercent(10) },
+			ExpectedErr: "Expected service 'test-service' to have candidate revision",
+		},
+		{
+			Desc:        "rollout percent out of range",
+			Func:        func() error { return release.SetRolloutPercent(100) },
+			ExpectedErr: "Expected rollout percentage '100' to be between 0% and 99%",
+		},
+	}
+
+	for _, ex := range examples {
+		err := ex.Func()
+		if err == nil {
+			t.Fatalf("[%s] Expected error", ex.Desc)
+		}
+		if err.Error() != ex.ExpectedErr {
+			t.Fatalf("[%s] Expected error '%s' but was '%s'", ex.Desc, ex.ExpectedErr, err)
+		}
+	}
+
+	servingClient.AddService(v1alpha1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-service", Namespace: "test-namespace"},
+		Spec:       v1alpha1.ServiceSpec{RunLatest: &v1alpha1.RunLatestType{}},
+	})
+
+	_, err := release.Promote()
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	expectedErr := "Expected service 'test-service' to be in release mode (use '--release' flag when running 'deploy' command)"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+}
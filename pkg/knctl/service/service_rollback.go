@@ -0,0 +1,190 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cppforlife/knctl/pkg/knctl/util"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ServiceRollback struct {
+	// Revision that 'latest' tag points to after rollback
+	LatestRevision *v1alpha1.Revision
+	// Routes that were updated to send traffic to last revision
+	PinnedRouteNames []string
+}
+
+// Rollback undoes CreateOrUpdate that produced failed revision. Previous service spec
+// is restored, which results in a new revision identical to the last revision;
+// it becomes 'latest' while last revision stays 'previous'. For services with unmanaged
+// routes traffic is pinned to last revision instead; it becomes 'latest' and
+// 'previous' tag is pointed back at the revision it was assigned to before deploy.
+// Tags are moved even if protected since they are only moved back from failed revision
+// (deploy is expected to check that protected tags are not moved before it saves service).
+func (s *Service) Rollback(lastRevision, failedRevision *v1alpha1.Revision) (ServiceRollback, error) {
+	tags := NewTags(s.servingClient).WithForce(true)
+
+	if !s.serviceSpec.NeedsConfigurationUpdate() {
+		err := s.RestorePreviousSpec()
+		if err != nil {
+			return ServiceRollback{}, err
+		}
+
+		restoredRevision, err := s.CreatedRevisionSinceRevision(failedRevision)
+		if err != nil {
+			return ServiceRollback{}, err
+		}
+
+		err = tags.Repoint(restoredRevision, TagsLatest)
+		if err != nil {
+			return ServiceRollback{}, err
+		}
+
+		return ServiceRollback{LatestRevision: restoredRevision}, nil
+	}
+
+	routeNames, err := s.PinRouteTraffic(lastRevision)
+	if err != nil {
+		return ServiceRollback{}, err
+	}
+
+	err = tags.Repoint(lastRevision, TagsLatest)
+	if err != nil {
+		return ServiceRollback{}, err
+	}
+
+	err = s.restorePreviousTag(lastRevision, tags)
+	if err != nil {
+		return ServiceRollback{}, err
+	}
+
+	return ServiceRollback{LatestRevision: lastRevision, PinnedRouteNames: routeNames}, nil
+}
+
+// RestorePreviousSpec updates service to the spec it had before CreateOrUpdate.
+// Knative creates a new revision from the restored revision template
+// (it can be found via CreatedRevisionSinceRevision).
+func (s *Service) RestorePreviousSpec() error {
+	if s.prevServiceSpec == nil {
+		return fmt.Errorf("Expected service '%s' to exist before deploy to restore its previous spec", s.serviceSpec.Name())
+	}
+
+	confSpec := ServiceConfigurationSpec(s.prevServiceSpec)
+	if confSpec == nil {
+		return fmt.Errorf("Expected previous spec of service '%s' to include configuration", s.serviceSpec.Name())
+	}
+
+	prevGeneration, err := s.configurationGeneration()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.updateService(v1alpha1.Service{Spec: *s.prevServiceSpec})
+	if err != nil {
+		return err
+	}
+
+	s.deployedTemplate = confSpec.RevisionTemplate.DeepCopy()
+	s.prevGeneration = prevGeneration
+
+	return nil
+}
+
+// restorePreviousTag points 'previous' tag back at the revision
+// it was assigned to before deploy moved it to last revision
+func (s *Service) restorePreviousTag(lastRevision *v1alpha1.Revision, tags Tags) error {
+	history := NewTagHistory(s.servingClient, s.serviceSpec.Namespace(), s.serviceSpec.Name())
+
+	entry, found, err := history.Last(TagsPrevious)
+	if err != nil {
+		return err
+	}
+
+	if !found || entry.RevisionName != lastRevision.Name || len(entry.PreviousRevisionName) == 0 {
+		return nil
+	}
+
+	prevRevision, err := s.servingClient.ServingV1alpha1().Revisions(s.serviceSpec.Namespace()).Get(entry.PreviousRevisionName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("Getting revision: %s", err)
+	}
+
+	return tags.RepointUndo(prevRevision, TagsPrevious)
+}
+
+// PinRouteTraffic updates routes that send traffic to the latest ready revision
+// of the service to send it to given revision instead, so that traffic does not
+// shift to a newer revision once it becomes ready. Returns names of updated routes.
+func (s *Service) PinRouteTraffic(revision *v1alpha1.Revision) ([]string, error) {
+	routes, err := s.servingClient.ServingV1alpha1().Routes(s.serviceSpec.Namespace()).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Listing routes: %s", err)
+	}
+
+	var updatedRouteNames []string
+
+	for _, route := range routes.Items {
+		var pinned bool
+
+		for i, target := range route.Spec.Traffic {
+			if target.ConfigurationName == s.serviceSpec.Name() {
+				route.Spec.Traffic[i].ConfigurationName = ""
+				route.Spec.Traffic[i].RevisionName = revision.Name
+				pinned = true
+			}
+		}
+
+		if !pinned {
+			continue
+		}
+
+		err := s.updateRouteTraffic(route)
+		if err != nil {
+			return nil, err
+		}
+
+		updatedRouteNames = append(updatedRouteNames, route.Name)
+	}
+
+	return updatedRouteNames, nil
+}
+
+func (s *Service) updateRouteTraffic(route v1alpha1.Route) error {
+	return util.Retry(time.Second, 10*time.Second, func() (bool, error) {
+		origRoute, err := s.servingClient.ServingV1alpha1().Routes(route.Namespace).Get(route.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("Getting route: %s", err)
+		}
+
+		origRoute.Spec.Traffic = route.Spec.Traffic
+
+		_, err = s.servingClient.ServingV1alpha1().Routes(route.Namespace).Update(origRoute)
+		if err != nil {
+			return false, fmt.Errorf("Updating route: %s", err)
+		}
+
+		return true, nil
+	})
+}
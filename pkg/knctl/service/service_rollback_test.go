@@ -0,0 +1,211 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	ctlbuild "github.com/cppforlife/knctl/pkg/knctl/build"
+	"github.com/cppforlife/knctl/pkg/knctl/fakes"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestServiceRestorePreviousSpec(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+
+	_, _, err := ctlservice.NewService(testServiceSpec{"test-image"}, servingClient, nil, nil, ctlbuild.Factory{}).CreateOrUpdate(false)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	prevSpec := servingClient.Service("test-namespace", "test-service").Spec
+
+	serviceObj := ctlservice.NewService(testServiceSpec{"failing-image"}, servingClient, nil, nil, ctlbuild.Factory{})
+
+	_, _, err = serviceObj.CreateOrUpdate(false)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	image := servingClient.Service("test-namespace", "test-service").Spec.RunLatest.Configuration.RevisionTemplate.Spec.Container.Image
+	if image != "failing-image" {
+		t.Fatalf("Expected image to be updated but was '%s'", image)
+	}
+
+	err = serviceObj.RestorePreviousSpec()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if !reflect.DeepEqual(servingClient.Service("test-namespace", "test-service").Spec, prevSpec) {
+		t.Fatalf("Expected spec '%#v' to equal '%#v'", servingClient.Service("test-namespace", "test-service").Spec, prevSpec)
+	}
+}
+
+func TestServiceRestorePreviousSpecForNewService(t *testing.T) {
+	serviceObj := ctlservice.NewService(testServiceSpec{"test-image"}, fakes.NewServingClient(), nil, nil, ctlbuild.Factory{})
+
+	_, _, err := serviceObj.CreateOrUpdate(false)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	err = serviceObj.RestorePreviousSpec()
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	expectedErr := "Expected service 'test-service' to exist before deploy to restore its previous spec"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+}
+
+func TestServiceRollbackTagsRevisionCreatedFromRestoredSpec(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+
+	var lastImage string
+	var generation int64
+
+	servingClient.AfterSave("services", func(obj runtime.Object) {
+		template := obj.(*v1alpha1.Service).Spec.RunLatest.Configuration.RevisionTemplate
+		if template.Spec.Container.Image == lastImage {
+			return // e.g. tag history update
+		}
+
+		lastImage = template.Spec.Container.Image
+		generation++
+
+		revision := testRevision(generation, fmt.Sprintf("test-service-%05d", generation), lastImage)
+		revision.Annotations = template.Annotations
+		servingClient.AddRevision(revision)
+
+		servingClient.AddConfiguration(testConfiguration(generation, generation, revision.Name))
+	})
+
+	_, _, err := ctlservice.NewService(testServiceSpec{"test-image"}, servingClient, nil, nil, ctlbuild.Factory{}).CreateOrUpdate(false)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	serviceObj := ctlservice.NewService(testServiceSpec{"failing-image"}, servingClient, nil, nil, ctlbuild.Factory{})
+
+	lastRevision, err := serviceObj.LastRevision()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	_, _, err = serviceObj.CreateOrUpdate(false)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	failedRevision, err := serviceObj.CreatedRevisionSinceRevision(lastRevision)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	tags := ctlservice.NewTags(servingClient)
+
+	for _, tagging := range []struct {
+		Revision *v1alpha1.Revision
+		Tag      string
+	}{
+		{lastRevision, ctlservice.TagsPrevious},
+		{failedRevision, ctlservice.TagsLatest},
+	} {
+		err = tags.Repoint(tagging.Revision, tagging.Tag)
+		if err != nil {
+			t.Fatalf("Expected no error: %s", err)
+		}
+	}
+
+	rollback, err := serviceObj.Rollback(lastRevision, failedRevision)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if rollback.LatestRevision.Name != "test-service-00003" {
+		t.Fatalf("Expected latest revision to be 'test-service-00003' but was '%s'", rollback.LatestRevision.Name)
+	}
+
+	expectedTags := map[string][]string{
+		"test-service-00001": []string{ctlservice.TagsPrevious},
+		"test-service-00002": nil,
+		"test-service-00003": []string{ctlservice.TagsLatest},
+	}
+
+	for _, revision := range servingClient.Revisions("test-namespace") {
+		revTags := tags.List(revision)
+		if !reflect.DeepEqual(revTags, expectedTags[revision.Name]) {
+			t.Fatalf("Expected revision '%s' tags '%#v' to equal '%#v'", revision.Name, revTags, expectedTags[revision.Name])
+		}
+	}
+}
+
+func TestServicePinRouteTraffic(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+
+	servingClient.AddRoute(v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "route1", Namespace: "test-namespace"},
+		Spec: v1alpha1.RouteSpec{
+			Traffic: []v1alpha1.TrafficTarget{
+				{ConfigurationName: "test-service", Percent: 80},
+				{RevisionName: "test-service-00001", Percent: 20},
+			},
+		},
+	})
+
+	servingClient.AddRoute(v1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "route2", Namespace: "test-namespace"},
+		Spec: v1alpha1.RouteSpec{
+			Traffic: []v1alpha1.TrafficTarget{{ConfigurationName: "other-service", Percent: 100}},
+		},
+	})
+
+	serviceObj := ctlservice.NewService(testServiceSpec{"test-image"}, servingClient, nil, nil, ctlbuild.Factory{})
+	revision := testRevision(2, "test-service-00002", "test-image")
+
+	routeNames, err := serviceObj.PinRouteTraffic(&revision)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if !reflect.DeepEqual(routeNames, []string{"route1"}) {
+		t.Fatalf("Expected only route 'route1' to be updated but was '%#v'", routeNames)
+	}
+
+	expectedTraffic := []v1alpha1.TrafficTarget{
+		{RevisionName: "test-service-00002", Percent: 80},
+		{RevisionName: "test-service-00001", Percent: 20},
+	}
+
+	if !reflect.DeepEqual(servingClient.Route("test-namespace", "route1").Spec.Traffic, expectedTraffic) {
+		t.Fatalf("Expected traffic '%#v' to equal '%#v'", servingClient.Route("test-namespace", "route1").Spec.Traffic, expectedTraffic)
+	}
+
+	expectedTraffic = []v1alpha1.TrafficTarget{{ConfigurationName: "other-service", Percent: 100}}
+
+	if !reflect.DeepEqual(servingClient.Route("test-namespace", "route2").Spec.Traffic, expectedTraffic) {
+		t.Fatalf("Expected traffic '%#v' to equal '%#v'", servingClient.Route("test-namespace", "route2").Spec.Traffic, expectedTraffic)
+	}
+}
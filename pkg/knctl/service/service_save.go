@@ -27,30 +27,193 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func (s *Service) CreateOrUpdate() (*v1alpha1.Service, error) {
-	service, err := s.serviceSpec.Service()
+// CreateOrUpdate saves service (and configuration for unmanaged routes)
+// unless desired revision template matches live one. Force results in a new revision
+// even if nothing has changed. Returns false if service was not updated.
+func (s *Service) CreateOrUpdate(force bool) (*v1alpha1.Service, bool, error) {
+	service, conf, deployedConfSpec, hash, err := s.desiredSpecs(force)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	conf, err := s.serviceSpec.Configuration()
+	if !force {
+		liveService, liveHash, err := s.liveRevisionTemplateHash()
+		if err != nil {
+			return nil, false, err
+		}
+
+		if liveService != nil && liveHash == hash {
+			return liveService, false, nil
+		}
+	}
+
+	prevGeneration, err := s.configurationGeneration()
+	if err != nil {
+		return nil, false, err
+	}
+
+	prevServiceSpec, err := s.liveServiceSpec()
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	createdService, err := s.createOrUpdateService(service)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	if s.serviceSpec.NeedsConfigurationUpdate() {
 		_, err = s.createOrUpdateConfiguration(createdService, conf)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 	}
 
-	return createdService, nil
+	s.deployedName = createdService.Name
+	s.deployedTemplate = deployedConfSpec.RevisionTemplate.DeepCopy()
+	s.prevGeneration = prevGeneration
+	s.prevServiceSpec = prevServiceSpec
+
+	return createdService, true, nil
+}
+
+// NeedsUpdate returns true if CreateOrUpdate would save service
+// (i.e. new revision would be created)
+func (s *Service) NeedsUpdate(force bool) (bool, error) {
+	if force {
+		return true, nil
+	}
+
+	_, _, _, hash, err := s.desiredSpecs(force)
+	if err != nil {
+		return false, err
+	}
+
+	liveService, liveHash, err := s.liveRevisionTemplateHash()
+	if err != nil {
+		return false, err
+	}
+
+	return liveService == nil || liveHash != hash, nil
+}
+
+// desiredSpecs returns service and configuration to be saved, configuration spec
+// that will produce new revision and hash of its revision template
+func (s *Service) desiredSpecs(force bool) (
+	v1alpha1.Service, v1alpha1.Configuration, *v1alpha1.ConfigurationSpec, string, error) {
+
+	service, err := s.serviceSpec.Service()
+	if err != nil {
+		return v1alpha1.Service{}, v1alpha1.Configuration{}, nil, "", err
+	}
+
+	conf, err := s.serviceSpec.Configuration()
+	if err != nil {
+		return v1alpha1.Service{}, v1alpha1.Configuration{}, nil, "", err
+	}
+
+	var deployedConfSpec *v1alpha1.ConfigurationSpec
+
+	if s.serviceSpec.NeedsConfigurationUpdate() {
+		deployedConfSpec = &conf.Spec
+	} else {
+		deployedConfSpec = ServiceConfigurationSpec(&service.Spec)
+	}
+
+	hash, err := withRevisionTemplateAnnotations(deployedConfSpec, force)
+	if err != nil {
+		return v1alpha1.Service{}, v1alpha1.Configuration{}, nil, "", err
+	}
+
+	return service, conf, deployedConfSpec, hash, nil
+}
+
+// liveRevisionTemplateHash returns live service and hash of its revision template
+// (recorded during previous deploy). Empty hash is returned if service was changed
+// in a way that desired hash cannot match.
+func (s *Service) liveRevisionTemplateHash() (*v1alpha1.Service, string, error) {
+	if len(s.serviceSpec.Name()) == 0 {
+		return nil, "", nil // service name is generated
+	}
+
+	liveService, err := s.servingClient.ServingV1alpha1().Services(s.serviceSpec.Namespace()).Get(s.serviceSpec.Name(), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("Getting service: %s", err)
+	}
+
+	if !s.serviceSpec.NeedsConfigurationUpdate() {
+		desiredService, err := s.serviceSpec.Service()
+		if err != nil {
+			return nil, "", err
+		}
+
+		// Switching between service modes (e.g. run latest to release) requires an update
+		if !sameServiceMode(liveService.Spec, desiredService.Spec) {
+			return liveService, "", nil
+		}
+
+		return liveService, ServiceConfigurationSpec(&liveService.Spec).RevisionTemplate.Annotations[RevisionTemplateHashAnnotationKey], nil
+	}
+
+	if liveService.Spec.Manual == nil {
+		return liveService, "", nil
+	}
+
+	liveConf, err := s.servingClient.ServingV1alpha1().Configurations(s.serviceSpec.Namespace()).Get(s.serviceSpec.Name(), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return liveService, "", nil
+		}
+		return nil, "", fmt.Errorf("Getting configuration: %s", err)
+	}
+
+	return liveService, liveConf.Spec.RevisionTemplate.Annotations[RevisionTemplateHashAnnotationKey], nil
+}
+
+func sameServiceMode(spec1, spec2 v1alpha1.ServiceSpec) bool {
+	return (spec1.RunLatest != nil) == (spec2.RunLatest != nil) &&
+		(spec1.Pinned != nil) == (spec2.Pinned != nil) &&
+		(spec1.Release != nil) == (spec2.Release != nil) &&
+		(spec1.Manual != nil) == (spec2.Manual != nil)
+}
+
+// configurationGeneration returns generation of the configuration
+// before it's updated so that newly created revisions could be identified
+func (s *Service) configurationGeneration() (int64, error) {
+	if len(s.serviceSpec.Name()) == 0 {
+		return 0, nil // service name is generated
+	}
+
+	conf, err := s.servingClient.ServingV1alpha1().Configurations(s.serviceSpec.Namespace()).Get(s.serviceSpec.Name(), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("Getting configuration: %s", err)
+	}
+
+	return conf.Spec.Generation, nil
+}
+
+// liveServiceSpec returns spec of the service before it's updated
+// so that it could be restored if new revision fails
+func (s *Service) liveServiceSpec() (*v1alpha1.ServiceSpec, error) {
+	if len(s.serviceSpec.Name()) == 0 {
+		return nil, nil // service name is generated
+	}
+
+	service, err := s.servingClient.ServingV1alpha1().Services(s.serviceSpec.Namespace()).Get(s.serviceSpec.Name(), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Getting service: %s", err)
+	}
+
+	return service.Spec.DeepCopy(), nil
 }
 
 func (s *Service) createOrUpdateService(service v1alpha1.Service) (*v1alpha1.Service, error) {
@@ -0,0 +1,298 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	ctlbuild "github.com/cppforlife/knctl/pkg/knctl/build"
+	"github.com/cppforlife/knctl/pkg/knctl/fakes"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/cppforlife/knctl/pkg/knctl/util"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestMain(m *testing.M) {
+	// Fake serving client reflects changes immediately
+	util.RetryInterval = time.Millisecond
+	os.Exit(m.Run())
+}
+
+var testCreationTime = metav1.NewTime(time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC))
+
+type testServiceSpec struct {
+	image string
+}
+
+func (s testServiceSpec) Namespace() string              { return "test-namespace" }
+func (s testServiceSpec) Name() string                   { return "test-service" }
+func (s testServiceSpec) NeedsConfigurationUpdate() bool { return false }
+
+func (s testServiceSpec) Service() (v1alpha1.Service, error) {
+	conf, err := s.Configuration()
+	if err != nil {
+		return v1alpha1.Service{}, err
+	}
+
+	return v1alpha1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: s.Name(), Namespace: s.Namespace()},
+		Spec: v1alpha1.ServiceSpec{
+			RunLatest: &v1alpha1.RunLatestType{Configuration: conf.Spec},
+		},
+	}, nil
+}
+
+func (s testServiceSpec) Configuration() (v1alpha1.Configuration, error) {
+	return v1alpha1.Configuration{
+		Spec: v1alpha1.ConfigurationSpec{
+			RevisionTemplate: v1alpha1.RevisionTemplateSpec{
+				Spec: v1alpha1.RevisionSpec{
+					Container: corev1.Container{Image: s.image},
+				},
+			},
+		},
+	}, nil
+}
+
+func testRevision(gen int64, name, image string) v1alpha1.Revision {
+	return v1alpha1.Revision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "test-namespace",
+			CreationTimestamp: testCreationTime,
+			Labels: map[string]string{
+				serving.ConfigurationLabelKey:                "test-service",
+				serving.ConfigurationGenerationAnnotationKey: fmt.Sprintf("%d", gen),
+			},
+		},
+		Spec: v1alpha1.RevisionSpec{
+			Generation: gen,
+			Container:  corev1.Container{Image: image},
+		},
+	}
+}
+
+func testConfiguration(gen, observedGen int64, latestCreatedRevisionName string) v1alpha1.Configuration {
+	return v1alpha1.Configuration{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-service", Namespace: "test-namespace"},
+		Spec:       v1alpha1.ConfigurationSpec{Generation: gen},
+		Status: v1alpha1.ConfigurationStatus{
+			ObservedGeneration:        observedGen,
+			LatestCreatedRevisionName: latestCreatedRevisionName,
+		},
+	}
+}
+
+func TestServiceCreatedRevisionSinceRevisionWithIdenticalTimestamps(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+	servingClient.AddService(v1alpha1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test-service", Namespace: "test-namespace"}})
+	servingClient.AddConfiguration(testConfiguration(1, 1, "test-service-00001"))
+	servingClient.AddRevision(testRevision(1, "test-service-00001", "test-image"))
+
+	servingClient.AfterSave("services", func(obj runtime.Object) {
+		template := obj.(*v1alpha1.Service).Spec.RunLatest.Configuration.RevisionTemplate
+
+		// Concurrent deploy of a different image
+		servingClient.AddRevision(testRevision(2, "test-service-zzzzz", "other-image"))
+
+		revision := testRevision(3, "test-service-00003", "test-image")
+		revision.Annotations = template.Annotations
+		servingClient.AddRevision(revision)
+	})
+
+	serviceObj := ctlservice.NewService(testServiceSpec{"test-image"}, servingClient, nil, nil, ctlbuild.Factory{})
+
+	lastRevision, err := serviceObj.LastRevision()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if lastRevision.Name != "test-service-00001" {
+		t.Fatalf("Expected last revision to be 'test-service-00001' but was '%s'", lastRevision.Name)
+	}
+
+	_, updated, err := serviceObj.CreateOrUpdate(false)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if !updated {
+		t.Fatalf("Expected service to be updated")
+	}
+
+	createdRevision, err := serviceObj.CreatedRevisionSinceRevision(lastRevision)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if createdRevision.Name != "test-service-00003" {
+		t.Fatalf("Expected created revision to be 'test-service-00003' but was '%s'", createdRevision.Name)
+	}
+}
+
+func TestServiceCreatedRevisionSinceRevisionTimesOut(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+
+	serviceObj := ctlservice.NewService(testServiceSpec{"test-image"}, servingClient, nil, nil, ctlbuild.Factory{})
+	serviceObj.WithRevisionCreationTimeout(10 * time.Millisecond)
+
+	_, _, err := serviceObj.CreateOrUpdate(false)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	// No revision is created since there is no configuration controller
+	_, err = serviceObj.CreatedRevisionSinceRevision(nil)
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	expectedErr := "Expected to find created revision within 10ms"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+}
+
+func TestServiceCreateOrUpdateSkipsUnchangedRevisionTemplate(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+
+	serviceObj := ctlservice.NewService(testServiceSpec{"test-image"}, servingClient, nil, nil, ctlbuild.Factory{})
+
+	for i, expectedUpdated := range []bool{true, false, false} {
+		_, updated, err := serviceObj.CreateOrUpdate(false)
+		if err != nil {
+			t.Fatalf("Expected no error: %s", err)
+		}
+
+		if updated != expectedUpdated {
+			t.Fatalf("[%d] Expected service update to be '%t' but was '%t'", i, expectedUpdated, updated)
+		}
+	}
+
+	_, updated, err := serviceObj.CreateOrUpdate(true)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if !updated {
+		t.Fatalf("Expected service to be updated when forced")
+	}
+
+	_, updated, err = ctlservice.NewService(testServiceSpec{"other-image"}, servingClient, nil, nil, ctlbuild.Factory{}).CreateOrUpdate(false)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if !updated {
+		t.Fatalf("Expected service to be updated when image changes")
+	}
+}
+
+func TestRevisionTemplateHashIgnoresKnctlAnnotations(t *testing.T) {
+	conf, err := testServiceSpec{"test-image"}.Configuration()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	hash1, err := ctlservice.RevisionTemplateHash(conf.Spec)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	conf.Spec.Generation = 5
+	conf.Spec.RevisionTemplate.Annotations = map[string]string{
+		ctlservice.RevisionTemplateHashAnnotationKey:  hash1,
+		ctlservice.RevisionTemplateForceAnnotationKey: "random",
+	}
+
+	hash2, err := ctlservice.RevisionTemplateHash(conf.Spec)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if hash1 != hash2 {
+		t.Fatalf("Expected hashes to match: '%s' vs '%s'", hash1, hash2)
+	}
+
+	conf.Spec.RevisionTemplate.Spec.Container.Env = []corev1.EnvVar{{Name: "key1", Value: "val1"}}
+
+	hash3, err := ctlservice.RevisionTemplateHash(conf.Spec)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if hash1 == hash3 {
+		t.Fatalf("Expected hashes to differ")
+	}
+}
+
+func TestServiceLastRevisionUsesLatestCreatedRevisionName(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+	servingClient.AddConfiguration(testConfiguration(2, 2, "test-service-00001"))
+	servingClient.AddRevision(testRevision(1, "test-service-00001", "test-image"))
+	servingClient.AddRevision(testRevision(2, "test-service-00002", "test-image"))
+
+	serviceObj := ctlservice.NewService(testServiceSpec{"test-image"}, servingClient, nil, nil, ctlbuild.Factory{})
+
+	lastRevision, err := serviceObj.LastRevision()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if lastRevision.Name != "test-service-00001" {
+		t.Fatalf("Expected last revision to be 'test-service-00001' but was '%s'", lastRevision.Name)
+	}
+}
+
+func TestServiceLastRevisionWithStaleConfigurationStatus(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+	servingClient.AddConfiguration(testConfiguration(3, 2, "test-service-00001"))
+	servingClient.AddRevision(testRevision(2, "test-service-00002", "test-image"))
+	servingClient.AddRevision(testRevision(10, "test-service-00010", "test-image"))
+	servingClient.AddRevision(testRevision(1, "test-service-00001", "test-image"))
+
+	serviceObj := ctlservice.NewService(testServiceSpec{"test-image"}, servingClient, nil, nil, ctlbuild.Factory{})
+
+	lastRevision, err := serviceObj.LastRevision()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if lastRevision.Name != "test-service-00010" {
+		t.Fatalf("Expected last revision to be 'test-service-00010' but was '%s'", lastRevision.Name)
+	}
+}
+
+func TestServiceLastRevisionWithoutConfiguration(t *testing.T) {
+	serviceObj := ctlservice.NewService(testServiceSpec{"test-image"}, fakes.NewServingClient(), nil, nil, ctlbuild.Factory{})
+
+	lastRevision, err := serviceObj.LastRevision()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if lastRevision != nil {
+		t.Fatalf("Expected no last revision but was '%s'", lastRevision.Name)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/user"
+	"time"
+
+	"github.com/cppforlife/knctl/pkg/knctl/util"
+	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	TagHistoryAnnotationKey = "cli.knative.dev/tagHistory"
+	TagHistoryMaxEntries    = 50
+)
+
+type TagHistoryEntry struct {
+	Tag                  string `json:"tag"`
+	RevisionName         string `json:"revision"`
+	PreviousRevisionName string `json:"previousRevision,omitempty"`
+	// LocalUser is OS user name of the machine that made the change
+	// (Kubernetes user is not known to the client)
+	LocalUser string    `json:"localUser,omitempty"`
+	Time      time.Time `json:"time"`
+	// Undo indicates that entry reverted earlier assignment of the tag
+	Undo bool `json:"undo,omitempty"`
+}
+
+// TagHistory keeps a bounded list of tag assignments (oldest first)
+// in an annotation on the service that owns tagged revisions
+type TagHistory struct {
+	servingClient servingclientset.Interface
+	namespace     string
+	serviceName   string
+}
+
+func NewTagHistory(servingClient servingclientset.Interface, namespace, serviceName string) TagHistory {
+	return TagHistory{servingClient, namespace, serviceName}
+}
+
+func (h TagHistory) List() ([]TagHistoryEntry, error) {
+	service, err := h.servingClient.ServingV1alpha1().Services(h.namespace).Get(h.serviceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Getting service: %s", err)
+	}
+
+	return h.entries(service.Annotations)
+}
+
+// Last returns most recent assignment of a tag
+func (h TagHistory) Last(tag string) (TagHistoryEntry, bool, error) {
+	entries, err := h.List()
+	if err != nil {
+		return TagHistoryEntry{}, false, err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Tag == tag {
+			return entries[i], true, nil
+		}
+	}
+
+	return TagHistoryEntry{}, false, nil
+}
+
+// LastUndoable returns most recent assignment of a tag that was not undone yet.
+// Undo entries are skipped together with assignments they reverted, so that
+// repeated undos keep walking back through the history.
+func (h TagHistory) LastUndoable(tag string) (TagHistoryEntry, bool, error) {
+	entries, err := h.List()
+	if err != nil {
+		return TagHistoryEntry{}, false, err
+	}
+
+	var undone int
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		switch {
+		case entries[i].Tag != tag:
+			continue
+		case entries[i].Undo:
+			undone++
+		case undone > 0:
+			undone--
+		default:
+			return entries[i], true, nil
+		}
+	}
+
+	return TagHistoryEntry{}, false, nil
+}
+
+// Record appends entry to the history. Revisions that do not
+// belong to a service (e.g. created via configurations) have no history.
+func (h TagHistory) Record(entry TagHistoryEntry) error {
+	if len(entry.LocalUser) == 0 {
+		entry.LocalUser = h.currentLocalUser()
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	return util.Retry(time.Second, 10*time.Second, func() (bool, error) {
+		service, err := h.servingClient.ServingV1alpha1().Services(h.namespace).Get(h.serviceName, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, fmt.Errorf("Getting service: %s", err)
+		}
+
+		entries, err := h.entries(service.Annotations)
+		if err != nil {
+			// Do not overwrite history that cannot be read
+			return true, err
+		}
+
+		entries = append(entries, entry)
+
+		if len(entries) > TagHistoryMaxEntries {
+			entries = entries[len(entries)-TagHistoryMaxEntries:]
+		}
+
+		entriesBytes, err := json.Marshal(entries)
+		if err != nil {
+			return true, fmt.Errorf("Serializing tag history: %s", err)
+		}
+
+		if service.Annotations == nil {
+			service.Annotations = map[string]string{}
+		}
+
+		service.Annotations[TagHistoryAnnotationKey] = string(entriesBytes)
+
+		_, err = h.servingClient.ServingV1alpha1().Services(h.namespace).Update(service)
+		if err != nil {
+			return false, fmt.Errorf("Updating service: %s", err)
+		}
+
+		return true, nil
+	})
+}
+
+func (h TagHistory) entries(anns map[string]string) ([]TagHistoryEntry, error) {
+	val, found := anns[TagHistoryAnnotationKey]
+	if !found {
+		return nil, nil
+	}
+
+	var entries []TagHistoryEntry
+
+	err := json.Unmarshal([]byte(val), &entries)
+	if err != nil {
+		return nil, fmt.Errorf("Deserializing tag history annotation '%s': %s", TagHistoryAnnotationKey, err)
+	}
+
+	return entries, nil
+}
+
+func (TagHistory) currentLocalUser() string {
+	currUser, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return currUser.Username
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/cppforlife/knctl/pkg/knctl/fakes"
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTagHistoryServingClient() *fakes.ServingClient {
+	servingClient := fakes.NewServingClient()
+
+	servingClient.AddService(v1alpha1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-service", Namespace: "test-namespace"},
+	})
+
+	for i := 1; i <= 2; i++ {
+		servingClient.AddRevision(v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("test-service-0000%d", i),
+				Namespace: "test-namespace",
+				Labels:    map[string]string{serving.ConfigurationLabelKey: "test-service"},
+			},
+		})
+	}
+
+	return servingClient
+}
+
+func TestTagsRepointRecordsHistory(t *testing.T) {
+	servingClient := newTagHistoryServingClient()
+	tags := ctlservice.NewTags(servingClient)
+
+	for _, name := range []string{"test-service-00001", "test-service-00002", "test-service-00002"} {
+		revision := &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test-namespace",
+				Labels:    map[string]string{serving.ConfigurationLabelKey: "test-service"},
+			},
+		}
+
+		err := tags.Repoint(revision, "stable")
+		if err != nil {
+			t.Fatalf("Expected no error: %s", err)
+		}
+	}
+
+	entries, err := ctlservice.NewTagHistory(servingClient, "test-namespace", "test-service").List()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	var actual [][]string
+
+	for _, entry := range entries {
+		if entry.Time.IsZero() {
+			t.Fatalf("Expected entry time to be set")
+		}
+		actual = append(actual, []string{entry.Tag, entry.RevisionName, entry.PreviousRevisionName})
+	}
+
+	// Repointing to the same revision is not recorded
+	expected := [][]string{
+		{"stable", "test-service-00001", ""},
+		{"stable", "test-service-00002", "test-service-00001"},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected history '%#v' to equal '%#v'", actual, expected)
+	}
+
+	revision, err := tags.Find(ctlservice.TagsFindContext{Namespace: "test-namespace", Service: "test-service"}, "stable")
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if revision.Name != "test-service-00002" {
+		t.Fatalf("Expected tag to point to latest revision but was '%s'", revision.Name)
+	}
+
+	err = tags.RepointUndo(&servingClient.Revisions("test-namespace")[0], "stable")
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	last, found, err := ctlservice.NewTagHistory(servingClient, "test-namespace", "test-service").Last("stable")
+	if err != nil || !found {
+		t.Fatalf("Expected to find last entry: %t, %v", found, err)
+	}
+
+	expectedLast := []interface{}{"test-service-00001", "test-service-00002", true}
+	actualLast := []interface{}{last.RevisionName, last.PreviousRevisionName, last.Undo}
+
+	if !reflect.DeepEqual(actualLast, expectedLast) {
+		t.Fatalf("Expected last entry '%#v' to equal '%#v'", actualLast, expectedLast)
+	}
+}
+
+func TestTagHistoryRecordIsBounded(t *testing.T) {
+	var existingEntries []ctlservice.TagHistoryEntry
+
+	for i := 0; i < ctlservice.TagHistoryMaxEntries; i++ {
+		existingEntries = append(existingEntries, ctlservice.TagHistoryEntry{Tag: fmt.Sprintf("tag%d", i), RevisionName: "rev"})
+	}
+
+	existingEntriesBytes, err := json.Marshal(existingEntries)
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	servingClient := fakes.NewServingClient()
+
+	servingClient.AddService(v1alpha1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-service",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{ctlservice.TagHistoryAnnotationKey: string(existingEntriesBytes)},
+		},
+	})
+
+	history := ctlservice.NewTagHistory(servingClient, "test-namespace", "test-service")
+
+	err = history.Record(ctlservice.TagHistoryEntry{Tag: "tag0", RevisionName: "new-rev"})
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	entries, err := history.List()
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if len(entries) != ctlservice.TagHistoryMaxEntries {
+		t.Fatalf("Expected history to have %d entries but was %d", ctlservice.TagHistoryMaxEntries, len(entries))
+	}
+
+	if entries[0].Tag != "tag1" {
+		t.Fatalf("Expected oldest entry to be dropped but first entry was '%s'", entries[0].Tag)
+	}
+
+	last, found, err := history.Last("tag0")
+	if err != nil || !found || last.RevisionName != "new-rev" {
+		t.Fatalf("Expected to find last entry for tag: %#v, %t, %v", last, found, err)
+	}
+
+	_, found, err = history.Last("missing-tag")
+	if err != nil || found {
+		t.Fatalf("Expected to not find entry: %t, %v", found, err)
+	}
+}
+
+func TestTagHistoryLastUndoableSkipsUndoneEntries(t *testing.T) {
+	existingEntries := []ctlservice.TagHistoryEntry{
+		{Tag: "stable", RevisionName: "rev1"},
+		{Tag: "stable", RevisionName: "rev2", PreviousRevisionName: "rev1"},
+		{Tag: "other", RevisionName: "rev1", PreviousRevisionName: "rev2"},
+		{Tag: "stable", RevisionName: "rev3", PreviousRevisionName: "rev2"},
+	}
+
+	servingClient := fakes.NewServingClient()
+	history := ctlservice.NewTagHistory(servingClient, "test-namespace", "test-service")
+
+	examples := []struct {
+		Undo             ctlservice.TagHistoryEntry
+		ExpectedRevision string
+		ExpectedFound    bool
+	}{
+		{ExpectedRevision: "rev3", ExpectedFound: true},
+		{
+			Undo:             ctlservice.TagHistoryEntry{Tag: "stable", RevisionName: "rev2", PreviousRevisionName: "rev3", Undo: true},
+			ExpectedRevision: "rev2",
+			ExpectedFound:    true,
+		},
+		{
+			Undo:             ctlservice.TagHistoryEntry{Tag: "stable", RevisionName: "rev1", PreviousRevisionName: "rev2", Undo: true},
+			ExpectedRevision: "rev1",
+			ExpectedFound:    true,
+		},
+		{
+			Undo:          ctlservice.TagHistoryEntry{Tag: "stable", RevisionName: "", PreviousRevisionName: "rev1", Undo: true},
+			ExpectedFound: false,
+		},
+	}
+
+	for i, ex := range examples {
+		if len(ex.Undo.Tag) > 0 {
+			existingEntries = append(existingEntries, ex.Undo)
+		}
+
+		existingEntriesBytes, err := json.Marshal(existingEntries)
+		if err != nil {
+			t.Fatalf("Expected no error: %s", err)
+		}
+
+		servingClient.AddService(v1alpha1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test-service",
+				Namespace:   "test-namespace",
+				Annotations: map[string]string{ctlservice.TagHistoryAnnotationKey: string(existingEntriesBytes)},
+			},
+		})
+
+		entry, found, err := history.LastUndoable("stable")
+		if err != nil {
+			t.Fatalf("Expected no error: %s", err)
+		}
+
+		if found != ex.ExpectedFound || entry.RevisionName != ex.ExpectedRevision {
+			t.Fatalf("[%d] Expected to find revision '%s' (%t) but was '%s' (%t)", i, ex.ExpectedRevision, ex.ExpectedFound, entry.RevisionName, found)
+		}
+	}
+}
+
+func TestTagHistoryRecordWithMalformedHistory(t *testing.T) {
+	servingClient := fakes.NewServingClient()
+
+	servingClient.AddService(v1alpha1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-service",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{ctlservice.TagHistoryAnnotationKey: "malformed"},
+		},
+	})
+
+	history := ctlservice.NewTagHistory(servingClient, "test-namespace", "test-service")
+
+	err := history.Record(ctlservice.TagHistoryEntry{Tag: "stable", RevisionName: "rev"})
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	expectedErr := "Retried 1 times: Deserializing tag history annotation 'cli.knative.dev/tagHistory': invalid character 'm' looking for beginning of value"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+
+	if servingClient.Service("test-namespace", "test-service").Annotations[ctlservice.TagHistoryAnnotationKey] != "malformed" {
+		t.Fatalf("Expected history to not be overwritten")
+	}
+}
+
+func TestTagHistoryRecordWithoutService(t *testing.T) {
+	history := ctlservice.NewTagHistory(fakes.NewServingClient(), "test-namespace", "missing-service")
+
+	err := history.Record(ctlservice.TagHistoryEntry{Tag: "stable", RevisionName: "rev"})
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+}
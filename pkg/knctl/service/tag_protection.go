@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cppforlife/knctl/pkg/knctl/util"
+	servingclientset "github.com/knative/serving/pkg/client/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Comma separated list of tags
+	TagProtectionAnnotationKey = "cli.knative.dev/protectedTags"
+)
+
+// TagProtection keeps list of tags that cannot be moved or removed
+// without force in an annotation on the service that owns tagged revisions
+type TagProtection struct {
+	servingClient servingclientset.Interface
+	namespace     string
+	serviceName   string
+}
+
+func NewTagProtection(servingClient servingclientset.Interface, namespace, serviceName string) TagProtection {
+	return TagProtection{servingClient, namespace, serviceName}
+}
+
+// List returns protected tags. Revisions that do not belong
+// to a service (e.g. created via configurations) have no protected tags.
+func (p TagProtection) List() ([]string, error) {
+	service, err := p.servingClient.ServingV1alpha1().Services(p.namespace).Get(p.serviceName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Getting service: %s", err)
+	}
+
+	return p.tags(service.Annotations), nil
+}
+
+func (p TagProtection) IsProtected(tag string) (bool, error) {
+	tags, err := p.List()
+	if err != nil {
+		return false, err
+	}
+
+	for _, protectedTag := range tags {
+		if protectedTag == tag {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (p TagProtection) Protect(tags []string) error {
+	return p.update(func(protectedTags map[string]struct{}) {
+		for _, tag := range tags {
+			protectedTags[tag] = struct{}{}
+		}
+	})
+}
+
+func (p TagProtection) Unprotect(tags []string) error {
+	return p.update(func(protectedTags map[string]struct{}) {
+		for _, tag := range tags {
+			delete(protectedTags, tag)
+		}
+	})
+}
+
+func (p TagProtection) update(updateFunc func(map[string]struct{})) error {
+	return util.Retry(time.Second, 10*time.Second, func() (bool, error) {
+		service, err := p.servingClient.ServingV1alpha1().Services(p.namespace).Get(p.serviceName, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("Getting service: %s", err)
+		}
+
+		protectedTags := map[string]struct{}{}
+
+		for _, tag := range p.tags(service.Annotations) {
+			protectedTags[tag] = struct{}{}
+		}
+
+		updateFunc(protectedTags)
+
+		var tags []string
+
+		for tag := range protectedTags {
+			tags = append(tags, tag)
+		}
+
+		sort.Strings(tags)
+
+		if service.Annotations == nil {
+			service.Annotations = map[string]string{}
+		}
+
+		if len(tags) > 0 {
+			service.Annotations[TagProtectionAnnotationKey] = strings.Join(tags, ",")
+		} else {
+			delete(service.Annotations, TagProtectionAnnotationKey)
+		}
+
+		_, err = p.servingClient.ServingV1alpha1().Services(p.namespace).Update(service)
+		if err != nil {
+			return false, fmt.Errorf("Updating service: %s", err)
+		}
+
+		return true, nil
+	})
+}
+
+func (TagProtection) tags(anns map[string]string) []string {
+	var tags []string
+
+	for _, tag := range strings.Split(anns[TagProtectionAnnotationKey], ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) > 0 {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"testing"
+
+	ctlservice "github.com/cppforlife/knctl/pkg/knctl/service"
+	"github.com/knative/serving/pkg/apis/serving"
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTagsProtectedTagIsNotMovedWithoutForce(t *testing.T) {
+	servingClient := newTagHistoryServingClient()
+
+	err := ctlservice.NewTagProtection(servingClient, "test-namespace", "test-service").Protect([]string{"prod"})
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	revision := func(name string) *v1alpha1.Revision {
+		return &v1alpha1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test-namespace",
+				Labels:    map[string]string{serving.ConfigurationLabelKey: "test-service"},
+			},
+		}
+	}
+
+	tags := ctlservice.NewTags(servingClient)
+
+	// Initial assignment of protected tag is allowed
+	err = tags.Repoint(revision("test-service-00001"), "prod")
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	err = tags.Repoint(revision("test-service-00002"), "prod")
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	expectedErr := "Expected tag 'prod' to not be moved from revision 'test-service-00001' since it is protected"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+
+	err = tags.Tag(revision("test-service-00002"), "prod")
+	if err == nil || err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%v'", expectedErr, err)
+	}
+
+	err = tags.Untag(*revision("test-service-00001"), "prod")
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	expectedErr = "Expected tag 'prod' to not be removed from revision 'test-service-00001' since it is protected"
+	if err.Error() != expectedErr {
+		t.Fatalf("Expected error '%s' but was '%s'", expectedErr, err)
+	}
+
+	err = tags.WithForce(true).Repoint(revision("test-service-00002"), "prod")
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	prodRevision, err := tags.Find(ctlservice.TagsFindContext{Namespace: "test-namespace", Service: "test-service"}, "prod")
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if prodRevision.Name != "test-service-00002" {
+		t.Fatalf("Expected tag to be moved with force but was '%s'", prodRevision.Name)
+	}
+}
+
+func TestTagProtectionProtectAndUnprotect(t *testing.T) {
+	servingClient := newTagHistoryServingClient()
+	protection := ctlservice.NewTagProtection(servingClient, "test-namespace", "test-service")
+
+	err := protection.Protect([]string{"prod", "canary", "prod"})
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	anns := servingClient.Service("test-namespace", "test-service").Annotations
+	if anns[ctlservice.TagProtectionAnnotationKey] != "canary,prod" {
+		t.Fatalf("Expected annotation to list protected tags but was '%s'", anns[ctlservice.TagProtectionAnnotationKey])
+	}
+
+	err = protection.Unprotect([]string{"canary", "prod"})
+	if err != nil {
+		t.Fatalf("Expected no error: %s", err)
+	}
+
+	if _, found := servingClient.Service("test-namespace", "test-service").Annotations[ctlservice.TagProtectionAnnotationKey]; found {
+		t.Fatalf("Expected annotation to be removed")
+	}
+
+	protected, err := protection.IsProtected("prod")
+	if err != nil || protected {
+		t.Fatalf("Expected tag to not be protected: %t, %v", protected, err)
+	}
+}
@@ -45,10 +45,17 @@ var (
 
 type Tags struct {
 	servingClient servingclientset.Interface
+	force         bool
 }
 
 func NewTags(servingClient servingclientset.Interface) Tags {
-	return Tags{servingClient}
+	return Tags{servingClient: servingClient}
+}
+
+// WithForce allows to move and remove protected tags
+func (t Tags) WithForce(force bool) Tags {
+	t.force = force
+	return t
 }
 
 func (t Tags) List(revision v1alpha1.Revision) []string {
@@ -68,6 +75,20 @@ type TagsFindContext struct {
 
 // TODO instead of context, pass in v1alpha1.Service?
 func (t Tags) Find(context TagsFindContext, tag string) (*v1alpha1.Revision, error) {
+	revision, found, err := t.TryFind(context, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("Expected to find revision with tag '%s' for service '%s'", tag, context.Service)
+	}
+
+	return revision, nil
+}
+
+// TryFind is like Find but returns false instead of an error when tag is not assigned
+func (t Tags) TryFind(context TagsFindContext, tag string) (*v1alpha1.Revision, bool, error) {
 	listOpts := metav1.ListOptions{
 		LabelSelector: labels.Set(map[string]string{
 			serving.ConfigurationLabelKey: context.Service,
@@ -77,23 +98,110 @@ func (t Tags) Find(context TagsFindContext, tag string) (*v1alpha1.Revision, err
 
 	revisions, err := t.servingClient.ServingV1alpha1().Revisions(context.Namespace).List(listOpts)
 	if err != nil {
-		return nil, fmt.Errorf("Listing revisions: %s", err)
+		return nil, false, fmt.Errorf("Listing revisions: %s", err)
 	}
 
 	switch len(revisions.Items) {
 	case 0:
-		return nil, fmt.Errorf("Expected to find revision with tag '%s' for service '%s'", tag, context.Service)
+		return nil, false, nil
 
 	case 1:
 		revision := revisions.Items[0]
-		return &revision, nil
+		return &revision, true, nil
 
 	default:
-		return nil, fmt.Errorf("Expected to find extactly one revision with tag '%s' for service '%s', but found multiple", tag, context.Service)
+		return nil, false, fmt.Errorf("Expected to find extactly one revision with tag '%s' for service '%s', but found multiple", tag, context.Service)
 	}
 }
 
+// Repoint moves tag to given revision and records
+// previous assignment in service's tag history
 func (t Tags) Repoint(revision *v1alpha1.Revision, tag string) error {
+	return t.repoint(revision, tag, false)
+}
+
+// RepointUndo moves tag back to given revision and records
+// the change as undo of the last assignment (see TagHistory.LastUndoable)
+func (t Tags) RepointUndo(revision *v1alpha1.Revision, tag string) error {
+	return t.repoint(revision, tag, true)
+}
+
+func (t Tags) repoint(revision *v1alpha1.Revision, tag string, undo bool) error {
+	prevRevisions, err := t.taggedRevisions(revision, tag)
+	if err != nil {
+		return err
+	}
+
+	err = t.checkMove(revision, tag, prevRevisions)
+	if err != nil {
+		return err
+	}
+
+	var prevRevisionName string
+
+	for _, prevRevision := range prevRevisions {
+		err := t.untag(prevRevision, tag)
+		if err != nil {
+			return err
+		}
+		prevRevisionName = prevRevision.Name
+	}
+
+	err = t.tag(revision, tag)
+	if err != nil {
+		return err
+	}
+
+	// Undo is always recorded so that it's matched with the assignment it reverted
+	if prevRevisionName == revision.Name && !undo {
+		return nil
+	}
+
+	history := NewTagHistory(t.servingClient, revision.Namespace, revision.Labels[serving.ConfigurationLabelKey])
+
+	err = history.Record(TagHistoryEntry{
+		Tag:                  tag,
+		RevisionName:         revision.Name,
+		PreviousRevisionName: prevRevisionName,
+		Undo:                 undo,
+	})
+	if err != nil {
+		return fmt.Errorf("Recording tag history: %s", err)
+	}
+
+	return nil
+}
+
+func (t Tags) Tag(revision *v1alpha1.Revision, tag string) error {
+	taggedRevisions, err := t.taggedRevisions(revision, tag)
+	if err != nil {
+		return err
+	}
+
+	err = t.checkMove(revision, tag, taggedRevisions)
+	if err != nil {
+		return err
+	}
+
+	return t.tag(revision, tag)
+}
+
+func (t Tags) Untag(revision v1alpha1.Revision, tag string) error {
+	if !t.force {
+		protected, err := t.protection(&revision).IsProtected(tag)
+		if err != nil {
+			return err
+		}
+
+		if protected {
+			return fmt.Errorf("Expected tag '%s' to not be removed from revision '%s' since it is protected", tag, revision.Name)
+		}
+	}
+
+	return t.untag(revision, tag)
+}
+
+func (t Tags) taggedRevisions(revision *v1alpha1.Revision, tag string) ([]v1alpha1.Revision, error) {
 	listOpts := metav1.ListOptions{
 		LabelSelector: labels.Set(map[string]string{
 			serving.ConfigurationLabelKey: revision.Labels[serving.ConfigurationLabelKey],
@@ -103,20 +211,47 @@ func (t Tags) Repoint(revision *v1alpha1.Revision, tag string) error {
 
 	revisions, err := t.servingClient.ServingV1alpha1().Revisions(revision.Namespace).List(listOpts)
 	if err != nil {
-		return fmt.Errorf("Listing revisions: %s", err)
+		return nil, fmt.Errorf("Listing revisions: %s", err)
 	}
 
-	for _, revision := range revisions.Items {
-		err := t.Untag(revision, tag)
-		if err != nil {
-			return err
+	return revisions.Items, nil
+}
+
+// checkMove returns an error if protected tag would be taken away from another revision
+func (t Tags) checkMove(revision *v1alpha1.Revision, tag string, taggedRevisions []v1alpha1.Revision) error {
+	if t.force {
+		return nil
+	}
+
+	var holderName string
+
+	for _, taggedRevision := range taggedRevisions {
+		if taggedRevision.Name != revision.Name {
+			holderName = taggedRevision.Name
 		}
 	}
 
-	return t.Tag(revision, tag)
+	if len(holderName) == 0 {
+		return nil
+	}
+
+	protected, err := t.protection(revision).IsProtected(tag)
+	if err != nil {
+		return err
+	}
+
+	if protected {
+		return fmt.Errorf("Expected tag '%s' to not be moved from revision '%s' since it is protected", tag, holderName)
+	}
+
+	return nil
 }
 
-func (t Tags) Tag(revision *v1alpha1.Revision, tag string) error {
+func (t Tags) protection(revision *v1alpha1.Revision) TagProtection {
+	return NewTagProtection(t.servingClient, revision.Namespace, revision.Labels[serving.ConfigurationLabelKey])
+}
+
+func (t Tags) tag(revision *v1alpha1.Revision, tag string) error {
 	// TODO support multiple tags
 	// TODO better namespacing?
 	mergePatch := map[string]interface{}{
@@ -141,7 +276,7 @@ func (t Tags) Tag(revision *v1alpha1.Revision, tag string) error {
 	})
 }
 
-func (t Tags) Untag(revision v1alpha1.Revision, tag string) error {
+func (t Tags) untag(revision v1alpha1.Revision, tag string) error {
 	encodedTag := rfc6901Encoder.Replace(t.label(tag))
 	patchJSON := []byte(fmt.Sprintf(`[{"op":"remove", "path":"/metadata/labels/%s"}]`, encodedTag))
 
@@ -23,9 +23,17 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
+// RetryInterval overrides interval passed to Retry when set
+// (e.g. tests use it to avoid waiting for fake clients)
+var RetryInterval time.Duration
+
 // Retry is different from wait.Poll because
 // it does not stop retrying when error is encountered
 func Retry(interval, timeout time.Duration, condFunc wait.ConditionFunc) error {
+	if RetryInterval > 0 {
+		interval = RetryInterval
+	}
+
 	var lastErr error
 	var times int
 
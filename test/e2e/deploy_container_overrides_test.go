@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"testing"
+)
+
+func TestDeployContainerOverrides(t *testing.T) {
+	logger := Logger{}
+	env := BuildEnv(t)
+	knctl := Knctl{t, env.Namespace, logger}
+	kubectl := Kubectl{t, env.Namespace, logger}
+	curl := Curl{t, knctl}
+
+	const (
+		serviceName     = "test-deploy-container-overrides-service-name"
+		expectedContent = "TestDeployContainerOverrides_Content"
+	)
+
+	cleanUp := func() {
+		knctl.RunWithOpts([]string{"service", "delete", "-s", serviceName}, RunOpts{AllowError: true})
+	}
+
+	logger.Section("Delete previous service with the same name if exists", cleanUp)
+	defer cleanUp()
+
+	logger.Section("Deploy service with custom entrypoint", func() {
+		knctl.Run([]string{
+			"deploy",
+			"-s", serviceName,
+			"-i", "gcr.io/knative-samples/helloworld-go",
+			"-e", "TARGET=" + expectedContent,
+			"--command", "/helloworld",
+		})
+	})
+
+	logger.Section("Checking if revision runs with custom entrypoint", func() {
+		curl.WaitForContent(serviceName, expectedContent)
+
+		out := kubectl.Run([]string{"get", "ksvc", serviceName, "-o",
+			"jsonpath={.spec.runLatest.configuration.revisionTemplate.spec.container.command}"})
+
+		if out != "[/helloworld]" {
+			t.Fatalf("Expected container command to be set, but was: '%s'", out)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDeployManifest(t *testing.T) {
+	logger := Logger{}
+	env := BuildEnv(t)
+	knctl := Knctl{t, env.Namespace, logger}
+	curl := Curl{t, knctl}
+
+	const (
+		serviceName      = "test-deploy-manifest-service-name"
+		expectedContent1 = "TestDeployManifest_Content1"
+		expectedContent2 = "TestDeployManifest_Content2"
+	)
+
+	manifestFile, err := ioutil.TempFile("", "knctl-deploy-manifest")
+	if err != nil {
+		t.Fatalf("Creating manifest file: %s", err)
+	}
+
+	defer os.Remove(manifestFile.Name())
+
+	manifest := `
+apiVersion: cli.knative.dev/v1alpha1
+kind: Deploy
+service: ` + serviceName + `
+image: gcr.io/knative-samples/helloworld-go
+env:
+- name: TARGET
+  value: ` + expectedContent1 + `
+tags: [manifest-tag]
+`
+
+	err = ioutil.WriteFile(manifestFile.Name(), []byte(manifest), 0600)
+	if err != nil {
+		t.Fatalf("Writing manifest file: %s", err)
+	}
+
+	cleanUp := func() {
+		knctl.RunWithOpts([]string{"service", "delete", "-s", serviceName}, RunOpts{AllowError: true})
+	}
+
+	logger.Section("Delete previous service with the same name if exists", cleanUp)
+	defer cleanUp()
+
+	logger.Section("Deploy service from manifest", func() {
+		knctl.Run([]string{"deploy", "-f", manifestFile.Name()})
+
+		curl.WaitForContent(serviceName, expectedContent1)
+
+		out := knctl.Run([]string{"revision", "show", "-r", serviceName + ":manifest-tag"})
+		if !strings.Contains(out, serviceName) {
+			t.Fatalf("Expected manifest tag to be applied to new revision, but was: %s", out)
+		}
+	})
+
+	logger.Section("Deploy service from manifest with flag overrides", func() {
+		knctl.Run([]string{"deploy", "-f", manifestFile.Name(), "-e", "TARGET=" + expectedContent2})
+
+		curl.WaitForContent(serviceName, expectedContent2)
+	})
+
+	logger.Section("Fail to deploy invalid manifest", func() {
+		err := ioutil.WriteFile(manifestFile.Name(), []byte(manifest+"imagee: typo\n"), 0600)
+		if err != nil {
+			t.Fatalf("Writing manifest file: %s", err)
+		}
+
+		_, err = knctl.RunWithOpts([]string{"deploy", "-f", manifestFile.Name()}, RunOpts{AllowError: true})
+		if err == nil {
+			t.Fatalf("Expected deploy to fail")
+		}
+		if !strings.Contains(err.Error(), "field imagee not found") || !strings.Contains(err.Error(), "line ") {
+			t.Fatalf("Expected error to include unknown field with line number, but was: %s", err)
+		}
+	})
+}
@@ -80,6 +80,43 @@ func TestBasicDeploy(t *testing.T) {
 		curl.WaitForContent(serviceName, expectedContent)
 	})
 
+	logger.Section("Deploy service without changes", func() {
+		out := knctl.Run([]string{
+			"deploy",
+			"-s", serviceName,
+			"-i", "gcr.io/knative-samples/helloworld-go",
+			"-e", "TARGET=" + expectedContent,
+		})
+
+		if !strings.Contains(out, "No changes to service") {
+			t.Fatalf("Expected deploy to report no changes, but did not: '%s'", out)
+		}
+
+		out = knctl.Run([]string{"revision", "list", "-s", serviceName, "--json"})
+		resp := uitest.JSONUIFromBytes(t, []byte(out))
+
+		if len(resp.Tables[0].Rows) != 1 {
+			t.Fatalf("Expected to see one revision in the list of revisions, but did not: '%s'", out)
+		}
+	})
+
+	logger.Section("Deploy service without changes forcefully", func() {
+		knctl.Run([]string{
+			"deploy",
+			"-s", serviceName,
+			"-i", "gcr.io/knative-samples/helloworld-go",
+			"-e", "TARGET=" + expectedContent,
+			"--force",
+		})
+
+		out := knctl.Run([]string{"revision", "list", "-s", serviceName, "--json"})
+		resp := uitest.JSONUIFromBytes(t, []byte(out))
+
+		if len(resp.Tables[0].Rows) != 2 {
+			t.Fatalf("Expected to see 2 revisions in the list of revisions, but did not: '%s'", out)
+		}
+	})
+
 	logger.Section("Check logs of service", func() {
 		expectedLogLines := []string{
 			"Hello world sample started.",